@@ -16,8 +16,14 @@ var (
 	// ErrParse is returned in case of parse (by clim) or validation error
 	// (by the user program).
 	// Check for this case with errors.Is(err, clim.ErrParse).
+	// For the details of a command-line error, see [ParseError].
 	// See also NewParseError.
 	ErrParse = errors.New("")
+	// ErrDefinition is returned in case of error in the definition of the
+	// command tree: a bug in the program, not in the command-line.
+	// Check for this case with errors.Is(err, clim.ErrDefinition).
+	// See also [CLI.Validate].
+	ErrDefinition = errors.New("")
 )
 
 // NewParseError creates an error that unwraps to [ErrParse].
@@ -36,6 +42,14 @@ func newHelpError(format string, a ...any) error {
 
 // CLI represents the top-level command, created with [New], and any
 // subcommands, created with [CLI.addCLI]. A [Flag] is added with [CLI.AddFlag].
+//
+// Some settings apply to the whole command tree and are read only from the
+// top-level CLI: [CLI.SetHelpWidth], [CLI.SetHelpMaxColumn],
+// [CLI.SetHelpRenderer], [CLI.SetShortHelp], [CLI.SetHelpCommand],
+// [CLI.SetColor], [CLI.SetUsageHint], [CLI.SetReportAll], [CLI.SetArityCheck],
+// [CLI.SetSchemaFlag], [CLI.SetWarningOutput] and [CLI.SetResponseFiles].
+// Calling one of them on a subcommand is a definition error, reported by
+// [CLI.Validate].
 type CLI[T any] struct {
 	name         string
 	oneline      string
@@ -47,14 +61,40 @@ type CLI[T any] struct {
 	short2long   map[string]string
 	posargs      *[]string
 	pairs        []Pair
-	longSeen     map[string]struct{} // Options seen on the command-line
-	positionals  []string
 	//
 	parent     *CLI[T]
 	rootToHere string
 	subCLIs    []*CLI[T]
 	action     func(uctx T) error
 	groups     []cliGroup[T]
+	//
+	flagGroups    []string // Names of the flag groups, in order.
+	constraints   []Constraint
+	validate      func() error
+	configLookup  ConfigLookup
+	hidden        bool      // See SetHidden.
+	deprecated    string    // See SetDeprecated.
+	replacedBy    string    // See SetDeprecated.
+	warnOut       io.Writer // See SetWarningOutput.
+	helpWidth     int       // See SetHelpWidth.
+	helpMaxCol    int       // See SetHelpMaxColumn.
+	renderer      HelpRenderer
+	responseFiles bool   // Expand @file arguments. See SetResponseFiles.
+	schemaFlag    string // See SetSchemaFlag.
+	helpCmd       bool   // See SetHelpCommand.
+	shortHelp     bool   // See SetShortHelp.
+	colorMode     ColorMode
+	colorOut      io.Writer
+	synopsisLines []string           // See SetSynopsis.
+	usageHint     bool               // See SetUsageHint.
+	reportAll     bool               // See SetReportAll.
+	arityCheck    bool               // See SetArityCheck.
+	defErrors     []error            // Definition errors, for Validate.
+	before        func(uctx T) error // See SetBefore.
+	after         func(uctx T) error // See SetAfter.
+	middlewares   []Middleware[T]    // See Use.
+	last          *Result[T]         // State of the last Parse.
+	topics        []helpTopic
 }
 
 type cliGroup[T any] struct {
@@ -71,7 +111,7 @@ type cliGroup[T any] struct {
 func NewTop[T any](name string, oneline string, action func(uctx T) error,
 ) (*CLI[T], error) {
 	if name == "" {
-		return nil, NewParseError("cli name cannot be empty")
+		return nil, newDefinitionError("cli name cannot be empty")
 	}
 	return newCli(nil, name, oneline, action), nil
 }
@@ -84,16 +124,16 @@ func NewTop[T any](name string, oneline string, action func(uctx T) error,
 func NewSub[T any](parent *CLI[T], name string, oneline string,
 	action func(uctx T) error,
 ) (*CLI[T], error) {
-	if name == "" {
-		return nil, NewParseError("cli name cannot be empty")
-	}
 	if parent == nil {
-		return nil, NewParseError("parent cli cannot be nil")
+		return nil, newDefinitionError("parent cli cannot be nil")
+	}
+	if name == "" {
+		return nil, parent.definitionError("cli name cannot be empty")
 	}
 	child := newCli(parent, name, oneline, action)
 	if parent.posargs != nil {
 		return nil,
-			NewParseError(
+			parent.definitionError(
 				"%s: already have pos args; cannot have also subcommand %q",
 				parent.name, child.name)
 	}
@@ -101,7 +141,7 @@ func NewSub[T any](parent *CLI[T], name string, oneline string,
 		if child.name == sc.name {
 			// `banana: long flag name "count" already defined`
 			return nil,
-				NewParseError("%s: subcommand %q already defined",
+				parent.definitionError("%s: subcommand %q already defined",
 					parent.rootToHere, child.name)
 		}
 	}
@@ -120,7 +160,6 @@ func newCli[T any](parent *CLI[T], name string, oneline string,
 		long2flag:  make(map[string]*Flag),
 		short2long: make(map[string]string),
 		// name2posarg: make(map[string]*PosArg),
-		longSeen: map[string]struct{}{},
 	}
 	child.rootToHere = strings.Join(pathRootToNode(child), " ")
 	return child
@@ -138,17 +177,44 @@ func (cli *CLI[T]) SetFooter(footer string) {
 	cli.footer = strings.TrimSpace(footer)
 }
 
+// SetValidate sets the validation hook of cli. [CLI.Parse] calls it once all
+// the flags and positional arguments of cli have been set, before returning
+// the action (or, if cli has subcommands, before parsing the subcommand).
+// A returned error is reported as a parse error.
+func (cli *CLI[T]) SetValidate(validate func() error) {
+	cli.validate = validate
+}
+
 // A Flag represents the state of a flag.
 // See also [CLI.AddFlag].
+//
+// A deprecated flag (field Deprecated not empty) still works, but each use
+// writes a warning (see [CLI.SetWarningOutput]). If field ReplacedBy is the
+// long name of another flag, the use is forwarded to it; in this case field
+// Value can be nil.
 type Flag struct {
-	Value    Value  // Final value, once parsed, mandatory.
-	Short    string // Short flag, optional.
-	Long     string // Long flag, mandatory.
-	Label    string // Placeholder in usage message, optional.
-	Help     string // Help text, optional.
+	Value Value  // Final value, once parsed, mandatory.
+	Short string // Short flag, optional.
+	Long  string // Long flag, mandatory.
+	Label string // Placeholder in usage message, optional.
+	Help  string // Help text, optional.
+	// Additional help text, shown only in the full help, optional.
+	// See [CLI.SetShortHelp].
+	LongHelp string
 	Required bool   // Optional, default false.
+	Env      string // Environment variable to take the value from, optional.
+	Hidden   bool   // Omit from the help, optional.
+	// Deprecation message, optional.
+	Deprecated string
+	// Long name of the flag replacing this deprecated one, optional.
+	ReplacedBy string
+	// Validate, optional, is called by [CLI.Parse] each time after
+	// Value has been set. A returned error is reported as a parse error.
+	Validate func() error
 	//
 	defValue string // Default value, for usage message. Taken from Value.
+	group    string // Name of the flag group, if any. See AddFlagGroup.
+	reset    func() // Restores the default value, if not nil. See resetFlags.
 }
 
 // AddFlags adds 'flags' to cli.
@@ -172,23 +238,23 @@ func (cli *CLI[T]) addFlag(flag *Flag) error {
 	//
 	if flag.Short != "" {
 		if strings.HasPrefix(flag.Short, "-") {
-			return NewParseError("short flag name must not begin with '-'")
+			return cli.definitionError("short flag name must not begin with '-'")
 		}
 		if strings.Contains(flag.Short, "=") {
-			return NewParseError("short flag name must not contain '='")
+			return cli.definitionError("short flag name must not contain '='")
 		}
 
 		// short can be empty.
 
 		if len(flag.Short) > 1 {
-			return NewParseError("short flag name %q must be exactly 1 character",
-				flag.Short)
+			return cli.definitionError(
+				"short flag name %q must be exactly 1 character", flag.Short)
 		}
 		if flag.Short == "h" {
-			return NewParseError(`cannot override short flag name "h"`)
+			return cli.definitionError(`cannot override short flag name "h"`)
 		}
 		if _, found := cli.short2long[flag.Short]; found {
-			return NewParseError("%s: short flag name %q already defined",
+			return cli.definitionError("%s: short flag name %q already defined",
 				cli.name, flag.Short)
 		}
 	}
@@ -197,36 +263,48 @@ func (cli *CLI[T]) addFlag(flag *Flag) error {
 	// Validate the long flag.
 	//
 	if strings.HasPrefix(flag.Long, "-") {
-		return NewParseError("long flag name %q must not begin with '-'", flag.Long)
+		return cli.definitionError("long flag name %q must not begin with '-'",
+			flag.Long)
 	}
 	if strings.Contains(flag.Long, "=") {
-		return NewParseError("long flag name %q must not contain '='", flag.Long)
+		return cli.definitionError("long flag name %q must not contain '='",
+			flag.Long)
 	}
 	if flag.Long == "" {
-		return NewParseError("long flag name cannot be empty")
+		return cli.definitionError("long flag name cannot be empty")
 	}
 	if len(flag.Long) < 2 {
-		return NewParseError("long flag name %q must be at least 2 characters",
+		return cli.definitionError("long flag name %q must be at least 2 characters",
 			flag.Long)
 	}
 	if flag.Long == "help" {
-		return NewParseError(`cannot override long flag name "help"`)
+		return cli.definitionError(`cannot override long flag name "help"`)
 	}
 	if _, found := cli.long2flag[flag.Long]; found {
-		return NewParseError("%s: long flag name %q already defined",
+		return cli.definitionError("%s: long flag name %q already defined",
 			cli.name, flag.Long)
 	}
 
-	// A variable can be bound to only one flag.
-	for k, fl := range cli.long2flag {
-		if fl.Value == flag.Value {
-			return NewParseError(
-				"long flag name %q: variable already bound to flag %q",
-				flag.Long, k)
+	if flag.Value == nil {
+		if flag.ReplacedBy == "" {
+			return cli.definitionError("long flag name %q: missing value", flag.Long)
+		}
+	} else {
+		// A variable can be bound to only one flag.
+		for k, fl := range cli.long2flag {
+			if fl.Value == flag.Value {
+				return cli.definitionError(
+					"long flag name %q: variable already bound to flag %q",
+					flag.Long, k)
+			}
 		}
+		flag.defValue = flag.Value.String()
+	}
+	if flag.ReplacedBy != "" && flag.Deprecated == "" {
+		return cli.definitionError(
+			"long flag name %q: ReplacedBy requires Deprecated", flag.Long)
 	}
 
-	flag.defValue = flag.Value.String()
 	if flag.Label == "" && !isBoolValue(flag.Value) {
 		flag.Label = strings.ToUpper(flag.Long)
 	}
@@ -243,11 +321,11 @@ func (cli *CLI[T]) addFlag(flag *Flag) error {
 // AddGroup adds the subclis to the group name.
 func (cli *CLI[T]) AddGroup(name string, clis ...*CLI[T]) error {
 	if len(clis) == 0 {
-		return NewParseError("AddGroup %s: child list is empty", name)
+		return cli.definitionError("AddGroup %s: child list is empty", name)
 	}
 	for _, child := range clis {
 		if !slices.Contains(cli.subCLIs, child) {
-			return NewParseError("AddGroup %s: child %s is missing previous AddCLI",
+			return cli.definitionError("AddGroup %s: child %s is missing previous AddCLI",
 				name, child.name)
 		}
 	}
@@ -255,47 +333,180 @@ func (cli *CLI[T]) AddGroup(name string, clis ...*CLI[T]) error {
 	return nil
 }
 
+// AddFlagGroup adds the flags to the group name. In the help, each group of
+// flags is rendered as a separate section with heading name, after the flags
+// that do not belong to any group. The flags must have already been added
+// with [CLI.AddFlags]; a flag can belong to only one group.
+func (cli *CLI[T]) AddFlagGroup(name string, flags ...*Flag) error {
+	if len(flags) == 0 {
+		return cli.definitionError("AddFlagGroup %s: flag list is empty", name)
+	}
+	if slices.Contains(cli.flagGroups, name) {
+		return cli.definitionError("AddFlagGroup %s: group already defined", name)
+	}
+	for _, flag := range flags {
+		if cli.long2flag[flag.Long] != flag {
+			return cli.definitionError(
+				"AddFlagGroup %s: flag %s is missing previous AddFlags",
+				name, flag.Long)
+		}
+		if flag.group != "" {
+			return cli.definitionError(
+				"AddFlagGroup %s: flag %s already belongs to group %s",
+				name, flag.Long, flag.group)
+		}
+	}
+	for _, flag := range flags {
+		flag.group = name
+	}
+	cli.flagGroups = append(cli.flagGroups, name)
+	return nil
+}
+
 // Parse processes args, following subcommands (if any), and returns the
 // associated action.
+// If enabled with [CLI.SetResponseFiles], it first expands any @file argument.
+// If enabled with [CLI.SetUsageHint], the message of a [ParseError] is
+// followed by a usage hint.
+// If enabled with [CLI.SetColor], the message of the returned error is styled.
+//
+// Before parsing, the variables bound to the flags of the command tree are
+// restored to their defaults, so that nothing leaks from the previous parsing.
+// The defaults are the values of the variables at the first parsing, thus
+// including any value assigned to them after [CLI.AddFlags].
+//
+// Parse keeps the state of the parsing (flags set, positional arguments,
+// selected command) in cli, replacing the one of the previous call, for
+// [CLI.IsSet], [CLI.FlagSource], [CLI.FlagsSet], [CLI.Positionals] and
+// [CLI.Selected]. To keep the state of each call instead, see
+// [CLI.ParseResult]. Parse is not safe for concurrent use.
 func (cli *CLI[T]) Parse(args []string) (func(uctx T) error, error) {
+	res := newResult[T]()
+	cli.root().last = res
+	if err := cli.parseInto(res, args); err != nil {
+		return nil, err
+	}
+	return res.action, nil
+}
+
+// ParseResult is like [CLI.Parse], but it returns the state of the parsing in
+// a new [Result], without modifying cli. Thus the same command tree can be
+// parsed many times (as in a REPL or in a table-driven test) without the state
+// of a call leaking into the next one.
+//
+// Note that the values are still written to the variables bound to the flags
+// and to the positional arguments (see [CLI.AddFlags] and [CLI.AddPosArgs]),
+// after restoring the defaults (see [CLI.Parse]). Thus, as Parse, it is not
+// safe for concurrent use.
+func (cli *CLI[T]) ParseResult(args []string) (*Result[T], error) {
+	res := newResult[T]()
+	if err := cli.parseInto(res, args); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// parseInto is the implementation of [CLI.Parse] and [CLI.ParseResult],
+// recording the state of the parsing in 'res'.
+func (cli *CLI[T]) parseInto(res *Result[T], args []string) error {
+	cli.resetFlags()
+	var action func(uctx T) error
+	var err error
+	if cli.root().responseFiles {
+		args, err = expandResponseFiles(cli.rootToHere, args, 0, -1)
+	}
+	if err == nil {
+		action, err = cli.parse(res, args, 0)
+	}
+	if err != nil {
+		res.selected = nil
+		if cli.root().usageHint {
+			err = cli.addUsageHint(err)
+		}
+		return cli.styleError(err)
+	}
+	res.action = action
+	return nil
+}
+
+// parse is the recursive implementation of [CLI.Parse]. Parameter 'base' is
+// the index of args[0] in the args of [CLI.Parse].
+func (cli *CLI[T]) parse(res *Result[T], args []string, base int,
+) (func(uctx T) error, error) {
+	errs := parseErrors{all: cli.root().reportAll}
 	index := 0
+	seen := res.seenOf(cli)
 
 	// Parse all the options. At the end of the loop, 'index' points to the
 	// beginning (if any) of the positional arguments.
 	for {
-		long, offset, err := cli.parseOne(args[index:])
+		long, offset, err := cli.parseOne(args[index:], base+index)
 		if err != nil {
-			return nil, err
+			if errs.add(err) || offset == 0 {
+				return nil, errs.err()
+			}
 		}
-		cli.longSeen[long] = struct{}{}
 		if offset == 0 {
 			// Arrived at the end of the options.
 			break
 		}
+		if long != "" {
+			seen[long] = SourceCommandLine
+		}
 		index += offset
 	}
 
+	// Special case: the help subcommand, see SetHelpCommand.
+	if index < len(args) && args[index] == "help" && cli.hasHelpCommand() {
+		return nil, cli.helpFor(args[index+1:], base+index+1)
+	}
+
+	// Options not set on the command-line can come from other sources.
+	if err := cli.setFromOtherSources(seen); err != nil {
+		if errs.add(err) {
+			return nil, errs.err()
+		}
+	}
+
 	// Are we missing any required options?
 	var missing []string
 	for name, flag := range cli.long2flag {
 		if !flag.Required {
 			continue
 		}
-		if _, found := cli.longSeen[name]; !found {
+		if _, found := seen[name]; !found {
 			missing = append(missing, name)
 		}
 	}
 	if len(missing) > 0 {
 		slices.Sort(missing)
-		return nil, NewParseError("missing required options: %s",
-			strings.Join(missing, ", "))
+		err := cli.newParseError(KindMissingRequired,
+			"missing required options: %s", strings.Join(missing, ", ")).
+			with(missing[0], nil)
+		if errs.add(err) {
+			return nil, errs.err()
+		}
+	}
+
+	// Are the constraints between options satisfied?
+	isSeen := func(long string) bool {
+		_, found := seen[long]
+		return found
+	}
+	for _, c := range cli.constraints {
+		if err := c.check(cli.rootToHere, isSeen); err != nil {
+			if errs.add(err) {
+				return nil, errs.err()
+			}
+		}
 	}
 
 	//
 	// Process the remaining of args (if any).
 	//
 
-	cli.positionals = args[index:]
+	positionals := args[index:]
+	res.positionals[cli] = positionals
 
 	if len(cli.subCLIs) > 0 && cli.posargs != nil {
 		return nil, fmt.Errorf(
@@ -303,32 +514,127 @@ func (cli *CLI[T]) Parse(args []string) (func(uctx T) error, error) {
 			cli.rootToHere)
 	}
 
+	//
+	// Positional arguments.
+	//
+	if cli.posargs != nil && cli.root().arityCheck {
+		if err := cli.checkArity(positionals, base+index); err != nil {
+			if errs.add(err) {
+				return nil, errs.err()
+			}
+		}
+	}
+	if cli.posargs != nil {
+		*cli.posargs = positionals
+	}
+
+	// The validation hook sees a consistent state only if there are no errors.
+	if cli.validate != nil && len(errs.errs) == 0 {
+		if err := cli.validate(); err != nil {
+			return nil, cli.newParseError(KindInvalidValue, "%s: %s",
+				cli.rootToHere, err).with("", err)
+		}
+	}
+
 	//
 	// Subcommand.
 	//
 	if len(cli.subCLIs) > 0 {
-		if len(cli.positionals) == 0 {
-			return nil, NewParseError("expected a command")
+		if len(positionals) == 0 {
+			errs.add(cli.newParseError(KindMissingCommand, "expected a command"))
+			return nil, errs.err()
 		}
-		command := cli.positionals[0]
+		command := positionals[0]
 		for _, p := range cli.subCLIs {
 			if p.name == command {
-				return p.Parse(cli.positionals[1:])
+				if p.deprecated != "" {
+					replacement, err := cli.forwardCLI(p)
+					if err != nil {
+						return nil, err
+					}
+					p = replacement
+				}
+				action, err := p.parse(res, positionals[1:], base+index+1)
+				if err != nil {
+					errs.add(err)
+				}
+				if err := errs.err(); err != nil {
+					return nil, err
+				}
+				return action, nil
 			}
 		}
-		return nil, NewParseError("unrecognized command %q", command)
+		errs.add(cli.newTokenError(KindUnknownCommand, command, base+index,
+			"unrecognized command %q", command))
+		return nil, errs.err()
 	}
 
-	//
-	// Positional arguments.
-	//
-	if cli.posargs != nil {
-		*cli.posargs = cli.positionals
+	if err := errs.err(); err != nil {
+		return nil, err
 	}
+	res.selected = cli
+	return cli.wrap(cli.run), nil
+}
 
-	return cli.run, nil
+// resetFlags restores the default values of the flags of the tree of cli, so
+// that nothing leaks from the previous parsing. At the first parsing, it takes
+// the defaults instead (see resetter).
+func (cli *CLI[T]) resetFlags() {
+	for _, node := range cli.tree() {
+		for _, flag := range node.long2flag {
+			if flag.reset == nil {
+				flag.reset = resetter(flag.Value)
+			} else {
+				flag.reset()
+			}
+		}
+	}
 }
 
+// SetArityCheck enables (or disables) the check of the number of positional
+// arguments against their names (see [Pair]): [CLI.Parse] returns a
+// [ParseError] of kind [KindArity] for a missing or extra positional argument.
+func (cli *CLI[T]) SetArityCheck(enable bool) {
+	cli.rootOnly("SetArityCheck")
+	cli.arityCheck = enable
+}
+
+// checkArity checks the number of 'positionals' against the arity of the pairs
+// (see [Pair]). Parameter 'at' is the index of the first positional argument
+// in the args of [CLI.Parse].
+func (cli *CLI[T]) checkArity(positionals []string, at int) error {
+	var required []string
+	variadic := false
+	for _, pair := range cli.pairs {
+		switch arity(pair.Name) {
+		case "1":
+			required = append(required, pair.Name)
+		case "1+":
+			required = append(required, pair.Name)
+			variadic = true
+		case "*":
+			variadic = true
+		}
+	}
+	maxCount := len(cli.pairs)
+	if len(positionals) < len(required) {
+		missing := required[len(positionals):]
+		return cli.newParseError(KindArity,
+			"missing positional arguments: %s", strings.Join(missing, " "))
+	}
+	if !variadic && len(positionals) > maxCount {
+		extra := positionals[maxCount:]
+		return cli.newTokenError(KindArity, extra[0], at+maxCount,
+			"too many positional arguments: %s", strings.Join(extra, " "))
+	}
+	return nil
+}
+
+// Pair is a positional argument: its name and its help. The name can mark
+// the argument as optional, as in "[FILE]", as repeated, as in "FILE...", or
+// both, as in "[FILE...]"; otherwise the argument is required. If enabled
+// with [CLI.SetArityCheck], [CLI.Parse] checks the number of positional
+// arguments accordingly.
 type Pair struct {
 	Name string
 	Help string
@@ -336,9 +642,8 @@ type Pair struct {
 
 func (cli *CLI[T]) AddPosArgs(values *[]string, pairs ...Pair) error {
 	if len(cli.subCLIs) > 0 {
-		// FIXME this is NOT a parse error!!!
-		return NewParseError("%s: already have subcommands; cannot have also pos args",
-			cli.name)
+		return cli.definitionError(
+			"%s: already have subcommands; cannot have also pos args", cli.name)
 	}
 
 	cli.posargs = values
@@ -347,12 +652,12 @@ func (cli *CLI[T]) AddPosArgs(values *[]string, pairs ...Pair) error {
 
 	for idx, pair := range pairs {
 		if idx2, found := names[pair.Name]; found {
-			return NewParseError(
+			return cli.definitionError(
 				"%s: pos arg at index %d (%q) was already defined at index %d",
 				cli.name, idx, pair.Name, idx2)
 		}
 		if pair.Name == "" {
-			return NewParseError("%s: pos arg at index %d (%q) cannot be empty",
+			return cli.definitionError("%s: pos arg at index %d (%q) cannot be empty",
 				cli.name, idx, pair.Name)
 		}
 
@@ -380,33 +685,6 @@ func CountTrue(args ...bool) int {
 	return n
 }
 
-// ExitCode is an helper function to reduce the boilerplate in your program.
-// It calls mainErr(args), prints the help or the error and returns an appropriate
-// exit code:
-//   - 0 if no error, or help requested
-//   - 2 if command-line parse error
-//   - 1 if any other errors
-//
-// Usage:
-//
-//	func main() {
-//	    os.Exit(clim.ExitCode(mainErr, os.Args[1:], os.Stderr))
-//	}
-func ExitCode(mainErr func(args []string) error, args []string, out io.Writer) int {
-	err := mainErr(args)
-	if err == nil {
-		return 0
-	}
-	fmt.Fprintln(out, err)
-	if errors.Is(err, ErrHelp) {
-		return 0
-	}
-	if errors.Is(err, ErrParse) {
-		return 2
-	}
-	return 1
-}
-
 // regex to match an option on the command-line.
 // _                           0  1              2            34  5
 var flagRE = regexp.MustCompile(`^(?P<hyphens>-*)(?P<name>.*?)((=)(?P<value>.+))?$`)
@@ -418,8 +696,11 @@ var flagRE = regexp.MustCompile(`^(?P<hyphens>-*)(?P<name>.*?)((=)(?P<value>.+))
 // end of options, beginning of positional arguments
 //
 // it returns the tuple (long, number_of_items_consumed (0, 1 or 2), error).
+// In case of [ParseError], it returns the number of items that the failed
+// option would have consumed, to continue parsing (see SetReportAll).
+// Parameter 'at' is the index of args[0] in the args of [CLI.Parse].
 // long is used by the caller to enforce required options.
-func (cli *CLI[T]) parseOne(args []string) (string, int, error) {
+func (cli *CLI[T]) parseOne(args []string, at int) (string, int, error) {
 	if len(args) == 0 {
 		return "", 0, nil
 	}
@@ -441,28 +722,50 @@ func (cli *CLI[T]) parseOne(args []string) (string, int, error) {
 	}
 
 	// Special case: help
+	if name == "h" && cli.root().shortHelp {
+		return "", 0, cli.briefUsage()
+	}
 	if name == "h" || name == "help" {
 		return "", 0, cli.usage()
 	}
 
+	// Special case: schema
+	if schemaFlag := cli.root().schemaFlag; schemaFlag != "" && name == schemaFlag {
+		return "", 0, cli.schemaError()
+	}
+
 	// Now we expect either a flag (short or long) or a parse error.
 
 	long := name
 	if len(name) == 1 {
 		long = cli.short2long[name]
 		if long == "" {
-			return "", 0, NewParseError("unrecognized flag %q", token)
+			return "", 1, cli.newTokenError(KindUnknownFlag, token, at,
+				"unrecognized flag %q", token)
 		}
 	}
 	flag := cli.long2flag[long]
 	if flag == nil {
-		return "", 0, NewParseError("unrecognized flag %q", token)
+		return "", 1, cli.newTokenError(KindUnknownFlag, token, at,
+			"unrecognized flag %q", token)
+	}
+	if flag.Deprecated != "" {
+		replacement, err := cli.forwardFlag(flag)
+		if err != nil {
+			return "", 0, err
+		}
+		flag = replacement
+		long = flag.Long
 	}
 
 	// Was the value provided in the same token, with "=" ?
 	if len(value) > 0 {
 		if err := flag.Value.Set(value); err != nil {
-			return "", 0, NewParseError("setting %q: %s", token, err)
+			return long, 1, cli.newTokenError(KindBadValue, token, at,
+				"setting %q: %s", token, err).with(long, err)
+		}
+		if err := cli.validateFlag(flag, token, at); err != nil {
+			return long, 1, err
 		}
 		return long, 1, nil
 	}
@@ -472,19 +775,40 @@ func (cli *CLI[T]) parseOne(args []string) (string, int, error) {
 			return "", 0, NewParseError("clim internal error: setting %q: %s",
 				token, err)
 		}
+		if err := cli.validateFlag(flag, token, at); err != nil {
+			return long, 1, err
+		}
 		return long, 1, nil
 	}
 
 	if len(args) == 1 {
-		return "", 0, NewParseError("flag %q requires a value", token)
+		return long, 1, cli.newTokenError(KindMissingValue, token, at,
+			"flag %q requires a value", token).with(long, nil)
 	}
 	nextValue := args[1]
 	if err := flag.Value.Set(nextValue); err != nil {
-		return "", 0, NewParseError("setting %q %q: %s", token, nextValue, err)
+		return long, 2, cli.newTokenError(KindBadValue, token, at,
+			"setting %q %q: %s", token, nextValue, err).with(long, err)
+	}
+	if err := cli.validateFlag(flag, token, at); err != nil {
+		return long, 2, err
 	}
 	return long, 2, nil
 }
 
+// validateFlag calls the validation hook (if any) of 'flag', set by 'token' at
+// index 'at' (or by another source if 'at' is -1).
+func (cli *CLI[T]) validateFlag(flag *Flag, token string, at int) error {
+	if flag.Validate == nil {
+		return nil
+	}
+	if err := flag.Validate(); err != nil {
+		return cli.newTokenError(KindInvalidValue, token, at, "%s: --%s: %s",
+			cli.rootToHere, flag.Long, err).with(flag.Long, err)
+	}
+	return nil
+}
+
 // pathRootToNode returns the CLI names in the tree path from the root to
 // 'node'.
 // TODO write test and add this to all errors?
@@ -502,9 +826,12 @@ func pathRootToNode[T any](node *CLI[T]) []string {
 	return path
 }
 
+// run is the action returned by [CLI.Parse]: it calls the action of cli,
+// surrounded by the hooks of the commands from the root to cli (see
+// [CLI.SetBefore] and [CLI.SetAfter]).
 func (cli *CLI[T]) run(uctx T) error {
 	if cli.action == nil {
-		return NewParseError("%s: no action registered", cli.rootToHere)
+		return newDefinitionError("%s: no action registered", cli.rootToHere)
 	}
-	return cli.action(uctx)
+	return cli.runHooks(uctx)
 }
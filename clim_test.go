@@ -2,12 +2,20 @@ package clim_test
 
 import (
 	"errors"
+	"os"
 	"testing"
 
 	"github.com/marco-m/clim"
 	"github.com/marco-m/rosina"
 )
 
+// TestMain makes the help tests hermetic: without COLUMNS, the help is wrapped
+// at the default width (see SetHelpWidth).
+func TestMain(m *testing.M) {
+	os.Unsetenv("COLUMNS")
+	os.Exit(m.Run())
+}
+
 func TestVariableCanBeBoundOnlyOnce(t *testing.T) {
 	var count int
 	cli, err := clim.NewTop[any]("banana", "I am tasty", nil)
@@ -185,7 +193,7 @@ func TestActionMissing(t *testing.T) {
 
 	rosina.AssertNoError(t, err)
 	err = action("hello")
-	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertErrorIs(t, err, clim.ErrDefinition)
 	rosina.AssertErrorContains(t, err, "basket: no action registered")
 }
 
@@ -409,6 +417,34 @@ func TestAddGroupMissingAddCLI(t *testing.T) {
 		"AddGroup ciccio: child child is missing previous AddCLI")
 }
 
+func TestAddFlagGroupFailure(t *testing.T) {
+	var count, level int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	countFlag := &clim.Flag{Value: clim.Int(&count, 0), Long: "count"}
+	err = cli.AddFlags(countFlag)
+	rosina.AssertNoError(t, err)
+	levelFlag := &clim.Flag{Value: clim.Int(&level, 0), Long: "level"}
+
+	err = cli.AddFlagGroup("ciccio")
+	rosina.AssertErrorContains(t, err, "AddFlagGroup ciccio: flag list is empty")
+
+	err = cli.AddFlagGroup("ciccio", levelFlag)
+	rosina.AssertErrorContains(t, err,
+		"AddFlagGroup ciccio: flag level is missing previous AddFlags")
+
+	err = cli.AddFlagGroup("ciccio", countFlag)
+	rosina.AssertNoError(t, err)
+
+	err = cli.AddFlagGroup("ciccio", countFlag)
+	rosina.AssertErrorContains(t, err,
+		"AddFlagGroup ciccio: group already defined")
+
+	err = cli.AddFlagGroup("pasticcio", countFlag)
+	rosina.AssertErrorContains(t, err,
+		"AddFlagGroup pasticcio: flag count already belongs to group ciccio")
+}
+
 func TestContTrue(t *testing.T) {
 	type testCase struct {
 		name string
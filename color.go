@@ -0,0 +1,135 @@
+// This file contains the optional ANSI styling of help and errors.
+
+package clim
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode selects when the help and the errors are styled with ANSI escape
+// sequences. See [CLI.SetColor].
+type ColorMode int
+
+const (
+	// ColorNever never styles the output. This is the default.
+	ColorNever ColorMode = iota
+	// ColorAuto styles the output if it is a terminal or if environment
+	// variable CLICOLOR_FORCE is set to a value other than "0".
+	ColorAuto
+	// ColorAlways always styles the output.
+	ColorAlways
+)
+
+// ANSI escape sequences.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiUnderline = "\x1b[4m"
+	ansiRed       = "\x1b[31m"
+)
+
+// SetColor sets when the help and the parse errors are styled with ANSI escape
+// sequences: headings and flag names in bold, placeholders underlined, errors
+// in red. Parameter 'out' is where the program prints the help and the errors
+// (usually os.Stderr, see [ExitCode]); it is used only by [ColorAuto], to
+// check whether it is a terminal.
+// In any mode, a non-empty environment variable NO_COLOR disables the styling
+// (see https://no-color.org).
+func (cli *CLI[T]) SetColor(mode ColorMode, out io.Writer) {
+	cli.rootOnly("SetColor")
+	cli.colorMode = mode
+	cli.colorOut = out
+}
+
+// color returns true if the output must be styled.
+func (cli *CLI[T]) color() bool {
+	root := cli.root()
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch root.colorMode {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+			return true
+		}
+		return isTerminal(root.colorOut)
+	default:
+		return false
+	}
+}
+
+// isTerminal returns true if 'w' is a terminal.
+func isTerminal(w io.Writer) bool {
+	fi, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := fi.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// styler applies the ANSI styles, if enabled.
+type styler bool
+
+func (st styler) apply(code string, text string) string {
+	if !st || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// heading styles a section heading.
+func (st styler) heading(text string) string {
+	return st.apply(ansiBold, text)
+}
+
+// name styles the name of a flag or of a command.
+func (st styler) name(text string) string {
+	return st.apply(ansiBold, text)
+}
+
+// placeholder styles a placeholder, such as the label of a flag.
+func (st styler) placeholder(text string) string {
+	return st.apply(ansiUnderline, text)
+}
+
+// pad returns 'styled' followed by the spaces needed to reach 'width' columns,
+// where 'plain' is 'styled' without the escape sequences.
+func pad(styled string, plain string, width int) string {
+	return styled + strings.Repeat(" ", max(width-len(plain), 0))
+}
+
+// styledError is an error with a styled message, that unwraps to the
+// original error.
+type styledError struct {
+	err  error
+	text string
+}
+
+func (se *styledError) Error() string {
+	return se.text
+}
+
+func (se *styledError) Unwrap() error {
+	return se.err
+}
+
+// styleError returns 'err' with its message in red, if the output must be
+// styled. The help is returned unchanged.
+func (cli *CLI[T]) styleError(err error) error {
+	if err == nil || errors.Is(err, ErrHelp) || !cli.color() {
+		return err
+	}
+	return &styledError{
+		err:  err,
+		text: styler(true).apply(ansiRed, err.Error()),
+	}
+}
@@ -0,0 +1,131 @@
+package clim_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestColor(t *testing.T) {
+	type testCase struct {
+		name          string
+		mode          clim.ColorMode
+		noColor       string
+		cliColorForce string
+		wantColor     bool
+	}
+
+	plainHelp := `bang -- bang head
+
+Usage: bang [options] NAME
+
+Options:
+
+ -c, --count N    How many times (default: 1)
+
+ -h, --help       Print this help and exit
+
+Positional arguments:
+
+ NAME      The name
+`
+	colorHelp := "\x1b[1mbang\x1b[0m -- bang head\n" +
+		"\n" +
+		"\x1b[1mUsage:\x1b[0m bang [options] NAME\n" +
+		"\n" +
+		"\x1b[1mOptions:\x1b[0m\n" +
+		"\n" +
+		" \x1b[1m-c\x1b[0m, \x1b[1m--count\x1b[0m \x1b[4mN\x1b[0m    How many times (default: 1)\n" +
+		"\n" +
+		" \x1b[1m-h\x1b[0m, \x1b[1m--help\x1b[0m       Print this help and exit\n" +
+		"\n" +
+		"\x1b[1mPositional arguments:\x1b[0m\n" +
+		"\n" +
+		" \x1b[4mNAME\x1b[0m      The name\n"
+	plainErr := `unrecognized flag "--banana"`
+	colorErr := "\x1b[31m" + plainErr + "\x1b[0m"
+
+	test := func(t *testing.T, tc testCase) {
+		t.Setenv("NO_COLOR", tc.noColor)
+		t.Setenv("CLICOLOR_FORCE", tc.cliColorForce)
+		var count int
+		var names []string
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetColor(tc.mode, &strings.Builder{})
+		err = cli.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 1), Short: "c", Long: "count", Label: "N",
+			Help: "How many times",
+		})
+		rosina.AssertNoError(t, err)
+		err = cli.AddPosArgs(&names, clim.Pair{"NAME", "The name"})
+		rosina.AssertNoError(t, err)
+		wantHelp, wantErr := plainHelp, plainErr
+		if tc.wantColor {
+			wantHelp, wantErr = colorHelp, colorErr
+		}
+
+		_, err = cli.Parse([]string{"-h"})
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		rosina.AssertTextEqual(t, err.Error(), wantHelp, "help message")
+
+		_, err = cli.Parse([]string{"--banana"})
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertEqual(t, err.Error(), wantErr, "error message")
+	}
+
+	testCases := []testCase{
+		{
+			name:      "never",
+			mode:      clim.ColorNever,
+			wantColor: false,
+		},
+		{
+			name:          "never, even if forced",
+			mode:          clim.ColorNever,
+			cliColorForce: "1",
+			wantColor:     false,
+		},
+		{
+			name:      "always",
+			mode:      clim.ColorAlways,
+			wantColor: true,
+		},
+		{
+			name:      "always, unless NO_COLOR",
+			mode:      clim.ColorAlways,
+			noColor:   "1",
+			wantColor: false,
+		},
+		{
+			name:      "auto, not a terminal",
+			mode:      clim.ColorAuto,
+			wantColor: false,
+		},
+		{
+			name:          "auto, forced",
+			mode:          clim.ColorAuto,
+			cliColorForce: "1",
+			wantColor:     true,
+		},
+		{
+			name:          "auto, forced to 0",
+			mode:          clim.ColorAuto,
+			cliColorForce: "0",
+			wantColor:     false,
+		},
+		{
+			name:          "auto, forced, unless NO_COLOR",
+			mode:          clim.ColorAuto,
+			noColor:       "1",
+			cliColorForce: "1",
+			wantColor:     false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
@@ -0,0 +1,205 @@
+// This file contains the constraints between flags, checked by Parse.
+
+package clim
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type constraintKind int
+
+const (
+	exactlyOne constraintKind = iota
+	atMostOne
+	atLeastOne
+	allOrNone
+	requires
+)
+
+// String returns the name of the kind, used in the [Schema].
+func (k constraintKind) String() string {
+	switch k {
+	case exactlyOne:
+		return "exactly-one"
+	case atMostOne:
+		return "at-most-one"
+	case atLeastOne:
+		return "at-least-one"
+	case allOrNone:
+		return "all-or-none"
+	case requires:
+		return "requires"
+	default:
+		return fmt.Sprintf("unknown-%d", int(k))
+	}
+}
+
+// A Constraint is a relationship between the flags of a [CLI], checked by
+// [CLI.Parse] after the required options. Create one with [ExactlyOne],
+// [AtMostOne], [AtLeastOne], [AllOrNone] or [Requires] and add it with
+// [CLI.AddConstraints].
+// A flag counts as specified when it has a value from any source other than the
+// default (see [CLI.FlagSource]), independently from its value. In a group of
+// mutually exclusive flags ([ExactlyOne], [AtMostOne]), a flag given on the
+// command-line takes precedence: the other flags of the group are not taken
+// from the environment or from the configuration.
+type Constraint struct {
+	kind  constraintKind
+	longs []string
+}
+
+// ExactlyOne creates a [Constraint] that requires exactly one of the flags
+// with long names 'longs' to be specified.
+func ExactlyOne(longs ...string) Constraint {
+	return Constraint{kind: exactlyOne, longs: longs}
+}
+
+// AtMostOne creates a [Constraint] that allows at most one of the flags with
+// long names 'longs' to be specified (that is, they are mutually exclusive).
+func AtMostOne(longs ...string) Constraint {
+	return Constraint{kind: atMostOne, longs: longs}
+}
+
+// AtLeastOne creates a [Constraint] that requires at least one of the flags
+// with long names 'longs' to be specified.
+func AtLeastOne(longs ...string) Constraint {
+	return Constraint{kind: atLeastOne, longs: longs}
+}
+
+// AllOrNone creates a [Constraint] that requires the flags with long names
+// 'longs' to be either all specified or all not specified.
+func AllOrNone(longs ...string) Constraint {
+	return Constraint{kind: allOrNone, longs: longs}
+}
+
+// Requires creates a [Constraint] that, if flag 'long' is specified, requires
+// also the flags 'others' to be specified.
+func Requires(long string, others ...string) Constraint {
+	return Constraint{kind: requires, longs: append([]string{long}, others...)}
+}
+
+// AddConstraints adds 'constraints' to cli. Each flag mentioned by a
+// constraint must have already been added with [CLI.AddFlags].
+func (cli *CLI[T]) AddConstraints(constraints ...Constraint) error {
+	for _, c := range constraints {
+		if len(c.longs) < 2 {
+			return cli.definitionError("%s: constraint %q: needs at least 2 flags",
+				cli.name, c.String())
+		}
+		seen := make(map[string]struct{}, len(c.longs))
+		for _, long := range c.longs {
+			if _, found := cli.long2flag[long]; !found {
+				return cli.definitionError("%s: constraint %q: unknown flag %q",
+					cli.name, c.String(), long)
+			}
+			if _, found := seen[long]; found {
+				return cli.definitionError("%s: constraint %q: duplicate flag %q",
+					cli.name, c.String(), long)
+			}
+			seen[long] = struct{}{}
+		}
+		cli.constraints = append(cli.constraints, c)
+	}
+	return nil
+}
+
+// String returns the description of the constraint, used in the help.
+func (c Constraint) String() string {
+	switch c.kind {
+	case exactlyOne:
+		return "exactly one of: " + dashes(c.longs)
+	case atMostOne:
+		return "at most one of: " + dashes(c.longs)
+	case atLeastOne:
+		return "at least one of: " + dashes(c.longs)
+	case allOrNone:
+		return "all or none of: " + dashes(c.longs)
+	case requires:
+		return dashes(c.longs[:1]) + " requires: " + dashes(c.longs[1:])
+	default:
+		return fmt.Sprintf("clim: internal error: unknown constraint %d", c.kind)
+	}
+}
+
+// check verifies the constraint against the flags in 'seen' and returns a
+// parse error in case of violation.
+// excludedByCommandLine reports whether the flag with long name 'long' belongs
+// to a group of mutually exclusive flags in which another flag has been given
+// on the command-line, according to 'seen'. See [Constraint].
+func (cli *CLI[T]) excludedByCommandLine(long string, seen map[string]Source,
+) bool {
+	for _, c := range cli.constraints {
+		if c.kind != exactlyOne && c.kind != atMostOne {
+			continue
+		}
+		if !slices.Contains(c.longs, long) {
+			continue
+		}
+		for _, other := range c.longs {
+			if other != long && seen[other] == SourceCommandLine {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c Constraint) check(path string, seen func(long string) bool) error {
+	var present, absent []string
+	for _, long := range c.longs {
+		if seen(long) {
+			present = append(present, long)
+		} else {
+			absent = append(absent, long)
+		}
+	}
+
+	switch c.kind {
+	case exactlyOne:
+		if len(present) == 0 {
+			return constraintError(path, "one of %s is required", dashes(c.longs))
+		}
+		if len(present) > 1 {
+			return constraintError(path, "only one of %s can be specified",
+				dashes(c.longs))
+		}
+	case atMostOne:
+		if len(present) > 1 {
+			return constraintError(path, "only one of %s can be specified",
+				dashes(c.longs))
+		}
+	case atLeastOne:
+		if len(present) == 0 {
+			return constraintError(path, "at least one of %s is required",
+				dashes(c.longs))
+		}
+	case allOrNone:
+		if len(present) > 0 && len(absent) > 0 {
+			return constraintError(path, "%s must be specified together; missing: %s",
+				dashes(c.longs), dashes(absent))
+		}
+	case requires:
+		if seen(c.longs[0]) && len(absent) > 0 {
+			return constraintError(path, "%s requires %s", dashes(c.longs[:1]),
+				dashes(absent))
+		}
+	}
+	return nil
+}
+
+// dashes returns the comma-separated list of 'longs', each prefixed by "--".
+func dashes(longs []string) string {
+	return "--" + strings.Join(longs, ", --")
+}
+
+// constraintError creates a [ParseError] of kind [KindConstraint].
+func constraintError(path string, format string, a ...any) error {
+	return &ParseError{
+		Kind:  KindConstraint,
+		Index: -1,
+		Path:  path,
+		msg:   fmt.Sprintf(format, a...),
+	}
+}
@@ -0,0 +1,282 @@
+package clim_test
+
+import (
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestConstraints(t *testing.T) {
+	type testCase struct {
+		name       string
+		constraint clim.Constraint
+		args       []string
+		wantErr    string // empty means no error
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var a, b, c bool
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(
+			&clim.Flag{Value: clim.Bool(&a, false), Long: "aa"},
+			&clim.Flag{Value: clim.Bool(&b, false), Long: "bb"},
+			&clim.Flag{Value: clim.Bool(&c, false), Long: "cc"},
+		)
+		rosina.AssertNoError(t, err)
+		err = cli.AddConstraints(tc.constraint)
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		if tc.wantErr == "" {
+			rosina.AssertNoError(t, err)
+			return
+		}
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:       "exactly one: none",
+			constraint: clim.ExactlyOne("aa", "bb"),
+			args:       nil,
+			wantErr:    "one of --aa, --bb is required",
+		},
+		{
+			name:       "exactly one: one",
+			constraint: clim.ExactlyOne("aa", "bb"),
+			args:       []string{"--bb"},
+		},
+		{
+			name:       "exactly one: two",
+			constraint: clim.ExactlyOne("aa", "bb"),
+			args:       []string{"--aa", "--bb"},
+			wantErr:    "only one of --aa, --bb can be specified",
+		},
+		{
+			name:       "at most one: none",
+			constraint: clim.AtMostOne("aa", "bb", "cc"),
+			args:       nil,
+		},
+		{
+			name:       "at most one: two",
+			constraint: clim.AtMostOne("aa", "bb", "cc"),
+			args:       []string{"--cc", "--aa"},
+			wantErr:    "only one of --aa, --bb, --cc can be specified",
+		},
+		{
+			name:       "at least one: none",
+			constraint: clim.AtLeastOne("aa", "bb"),
+			args:       nil,
+			wantErr:    "at least one of --aa, --bb is required",
+		},
+		{
+			name:       "at least one: two",
+			constraint: clim.AtLeastOne("aa", "bb"),
+			args:       []string{"--aa", "--bb"},
+		},
+		{
+			name:       "all or none: none",
+			constraint: clim.AllOrNone("aa", "bb", "cc"),
+			args:       nil,
+		},
+		{
+			name:       "all or none: all",
+			constraint: clim.AllOrNone("aa", "bb", "cc"),
+			args:       []string{"--aa", "--bb", "--cc"},
+		},
+		{
+			name:       "all or none: some",
+			constraint: clim.AllOrNone("aa", "bb", "cc"),
+			args:       []string{"--bb"},
+			wantErr: "--aa, --bb, --cc must be specified together; " +
+				"missing: --aa, --cc",
+		},
+		{
+			name:       "requires: trigger absent",
+			constraint: clim.Requires("aa", "bb", "cc"),
+			args:       []string{"--bb"},
+		},
+		{
+			name:       "requires: satisfied",
+			constraint: clim.Requires("aa", "bb", "cc"),
+			args:       []string{"--aa", "--bb", "--cc"},
+		},
+		{
+			name:       "requires: missing",
+			constraint: clim.Requires("aa", "bb", "cc"),
+			args:       []string{"--aa", "--cc"},
+			wantErr:    "--aa requires --bb",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestConstraintsWithOtherSources(t *testing.T) {
+	type testCase struct {
+		name       string
+		constraint clim.Constraint
+		env        map[string]string
+		args       []string
+		wantA      int
+		wantB      int
+		wantErr    string // empty means no error
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		for k, v := range tc.env {
+			t.Setenv(k, v)
+		}
+		var a, b int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(
+			&clim.Flag{Value: clim.Int(&a, 0), Long: "aa", Env: "PROBE_A"},
+			&clim.Flag{Value: clim.Int(&b, 0), Long: "bb", Env: "PROBE_B"},
+		)
+		rosina.AssertNoError(t, err)
+		err = cli.AddConstraints(tc.constraint)
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		if tc.wantErr != "" {
+			rosina.AssertErrorIs(t, err, clim.ErrParse)
+			rosina.AssertErrorContains(t, err, tc.wantErr)
+			return
+		}
+		rosina.AssertNoError(t, err)
+		rosina.AssertEqual(t, a, tc.wantA, "aa")
+		rosina.AssertEqual(t, b, tc.wantB, "bb")
+	}
+
+	testCases := []testCase{
+		{
+			name:       "at most one: command-line takes precedence over env",
+			constraint: clim.AtMostOne("aa", "bb"),
+			env:        map[string]string{"PROBE_A": "1"},
+			args:       []string{"--bb", "2"},
+			wantA:      0,
+			wantB:      2,
+		},
+		{
+			name:       "at most one: both from env",
+			constraint: clim.AtMostOne("aa", "bb"),
+			env:        map[string]string{"PROBE_A": "1", "PROBE_B": "2"},
+			wantErr:    "only one of --aa, --bb can be specified",
+		},
+		{
+			name:       "exactly one: satisfied by env",
+			constraint: clim.ExactlyOne("aa", "bb"),
+			env:        map[string]string{"PROBE_A": "1"},
+			wantA:      1,
+		},
+		{
+			name:       "requires: satisfied by env",
+			constraint: clim.Requires("aa", "bb"),
+			env:        map[string]string{"PROBE_B": "2"},
+			args:       []string{"--aa", "1"},
+			wantA:      1,
+			wantB:      2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestAddConstraintsFailure(t *testing.T) {
+	type testCase struct {
+		name       string
+		constraint clim.Constraint
+		wantErr    string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var a, b bool
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(
+			&clim.Flag{Value: clim.Bool(&a, false), Long: "aa"},
+			&clim.Flag{Value: clim.Bool(&b, false), Long: "bb"},
+		)
+		rosina.AssertNoError(t, err)
+
+		err = cli.AddConstraints(tc.constraint)
+
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:       "too few flags",
+			constraint: clim.AtMostOne("aa"),
+			wantErr:    `bang: constraint "at most one of: --aa": needs at least 2 flags`,
+		},
+		{
+			name:       "unknown flag",
+			constraint: clim.ExactlyOne("aa", "zz"),
+			wantErr:    `bang: constraint "exactly one of: --aa, --zz": unknown flag "zz"`,
+		},
+		{
+			name:       "duplicate flag",
+			constraint: clim.Requires("aa", "aa"),
+			wantErr:    `bang: constraint "--aa requires: --aa": duplicate flag "aa"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHelpOfConstraints(t *testing.T) {
+	var a, b, c bool
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(
+		&clim.Flag{Value: clim.Bool(&a, false), Long: "aa"},
+		&clim.Flag{Value: clim.Bool(&b, false), Long: "bb"},
+		&clim.Flag{Value: clim.Bool(&c, false), Long: "cc"},
+	)
+	rosina.AssertNoError(t, err)
+	err = cli.AddConstraints(
+		clim.ExactlyOne("aa", "bb"),
+		clim.AtLeastOne("bb", "cc"),
+		clim.AllOrNone("aa", "cc"),
+		clim.Requires("cc", "aa", "bb"),
+	)
+	rosina.AssertNoError(t, err)
+
+	want := `bang -- bang head
+
+Usage: bang (--aa | --bb) [options]
+
+Options:
+
+ --aa           (default: false)
+ --bb           (default: false)
+ --cc           (default: false)
+
+ -h, --help    Print this help and exit
+
+Constraints:
+
+ exactly one of: --aa, --bb
+ at least one of: --bb, --cc
+ all or none of: --aa, --cc
+ --cc requires: --aa, --bb
+`
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
@@ -0,0 +1,110 @@
+// This file contains the errors in the definition of the command tree.
+
+package clim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// newDefinitionError creates an error that unwraps to [ErrDefinition].
+func newDefinitionError(format string, a ...any) error {
+	return fmt.Errorf("%w%s", ErrDefinition, fmt.Sprintf(format, a...))
+}
+
+// definitionError creates an error that unwraps to [ErrDefinition] and records
+// it in cli, so that [CLI.Validate] reports it even if the caller ignored it.
+func (cli *CLI[T]) definitionError(format string, a ...any) error {
+	err := newDefinitionError(format, a...)
+	cli.defErrors = append(cli.defErrors, err)
+	return err
+}
+
+// rootOnly records a definition error if cli is not the top-level CLI. It is
+// called by the setters of the settings of the whole command tree, which are
+// read only from the top-level CLI (see [CLI]).
+func (cli *CLI[T]) rootOnly(setter string) {
+	if cli.parent != nil {
+		cli.definitionError("%s: %s: must be called on the top-level CLI",
+			cli.rootToHere, setter)
+	}
+}
+
+// Validate checks the definition of cli and of all its subcommands, and
+// returns all the problems found, joined with [errors.Join], or nil. Each
+// problem unwraps to [ErrDefinition]. It reports:
+//   - the errors already returned by the definition functions, such as
+//     [NewSub], [CLI.AddFlags] or [CLI.AddGroup], in case the program
+//     ignored them;
+//   - the commands with neither action nor subcommands;
+//   - the deprecated flags and subcommands whose replacement is not defined;
+//   - the help topics shadowed by a subcommand with the same name;
+//   - the flags shadowed by the schema flag (see [CLI.SetSchemaFlag]);
+//   - the settings of the whole tree set on a subcommand (see [CLI]).
+//
+// Call it on the top-level CLI from a unit test, to catch the bugs in the
+// definition before the users do:
+//
+//	func TestCLIDefinition(t *testing.T) {
+//	    cli, err := newCLI()
+//	    ...
+//	    if err := cli.Validate(); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+func (cli *CLI[T]) Validate() error {
+	var errs []error
+	for _, node := range cli.tree() {
+		errs = append(errs, node.defErrors...)
+		errs = append(errs, node.validateNode()...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateNode returns the problems of the definition of cli, without
+// considering its subcommands.
+func (cli *CLI[T]) validateNode() []error {
+	var errs []error
+	if cli.action == nil && len(cli.subCLIs) == 0 {
+		errs = append(errs, newDefinitionError("%s: no action registered",
+			cli.rootToHere))
+	}
+	schemaFlag := cli.root().schemaFlag
+	for _, long := range cli.orderedFlags {
+		flag := cli.long2flag[long]
+		if flag.ReplacedBy != "" && cli.long2flag[flag.ReplacedBy] == nil {
+			errs = append(errs, newDefinitionError(
+				"%s: flag \"--%s\": replacement flag \"--%s\" not defined",
+				cli.rootToHere, flag.Long, flag.ReplacedBy))
+		}
+		if long == schemaFlag {
+			errs = append(errs, newDefinitionError(
+				"%s: long flag name %q: shadowed by the schema flag",
+				cli.rootToHere, long))
+		}
+	}
+	for _, sub := range cli.subCLIs {
+		if sub.replacedBy != "" && cli.findSub(sub.replacedBy) == nil {
+			errs = append(errs, newDefinitionError(
+				"%s: command %q: replacement command %q not defined",
+				cli.rootToHere, sub.name, sub.replacedBy))
+		}
+	}
+	for _, topic := range cli.topics {
+		if cli.findSub(topic.name) != nil {
+			errs = append(errs, newDefinitionError(
+				"%s: help topic %q: shadowed by the subcommand with the same name",
+				cli.rootToHere, topic.name))
+		}
+	}
+	return errs
+}
+
+// tree returns cli and all its subcommands, recursively, depth-first.
+func (cli *CLI[T]) tree() []*CLI[T] {
+	nodes := []*CLI[T]{cli}
+	for _, sub := range cli.subCLIs {
+		nodes = append(nodes, sub.tree()...)
+	}
+	return nodes
+}
@@ -0,0 +1,124 @@
+package clim_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestDefinitionErrorIsNotParseError(t *testing.T) {
+	var count int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+
+	err = cli.AddFlags(&clim.Flag{Value: clim.Int(&count, 0), Long: "x"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrDefinition)
+	rosina.AssertEqual(t, errors.Is(err, clim.ErrParse), false, "is ErrParse")
+	out := &strings.Builder{}
+	code := clim.ExitCode(func([]string) error { return err }, nil, out)
+	rosina.AssertEqual(t, code, 1, "exit code")
+}
+
+func TestValidateSuccess(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub[any](cli, "sub", "I am sub",
+		func(uctx any) error { return nil })
+	rosina.AssertNoError(t, err)
+
+	rosina.AssertNoError(t, cli.Validate())
+}
+
+func TestValidateFailure(t *testing.T) {
+	var count, old int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetSchemaFlag("schema")
+	action := func(uctx any) error { return nil }
+
+	// Errors ignored by the program.
+	_ = cli.AddFlags(&clim.Flag{Value: clim.Int(&count, 0), Long: "x"})
+	_, _ = clim.NewSub[any](cli, "", "No name", action)
+
+	err = cli.AddFlags(
+		&clim.Flag{Value: clim.Int(&old, 0), Long: "schema"},
+		&clim.Flag{Long: "old", Deprecated: "renamed", ReplacedBy: "new"})
+	rosina.AssertNoError(t, err)
+	err = cli.AddHelpTopic("sub", "A topic", "text")
+	rosina.AssertNoError(t, err)
+	sub, err := clim.NewSub[any](cli, "sub", "I am sub", nil)
+	rosina.AssertNoError(t, err)
+	rm, err := clim.NewSub[any](cli, "rm", "I am rm", action)
+	rosina.AssertNoError(t, err)
+	rm.SetDeprecated("renamed", "remove")
+	_, err = clim.NewSub[any](sub, "leaf", "I am a leaf", nil)
+	rosina.AssertNoError(t, err)
+
+	err = cli.Validate()
+
+	rosina.AssertErrorIs(t, err, clim.ErrDefinition)
+	want := `long flag name "x" must be at least 2 characters
+cli name cannot be empty
+bang: long flag name "schema": shadowed by the schema flag
+bang: flag "--old": replacement flag "--new" not defined
+bang: command "rm": replacement command "remove" not defined
+bang: help topic "sub": shadowed by the subcommand with the same name
+bang sub leaf: no action registered`
+	rosina.AssertTextEqual(t, err.Error(), want, "error message")
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		rosina.AssertErrorIs(t, e, clim.ErrDefinition)
+	}
+}
+
+func TestValidateTreeSettingOnSubcommand(t *testing.T) {
+	type testCase struct {
+		name    string
+		setter  func(sub *clim.CLI[any])
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		sub, err := clim.NewSub[any](cli, "sub", "I am sub",
+			func(uctx any) error { return nil })
+		rosina.AssertNoError(t, err)
+
+		tc.setter(sub)
+
+		err = cli.Validate()
+		rosina.AssertErrorIs(t, err, clim.ErrDefinition)
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:    "SetHelpWidth",
+			setter:  func(sub *clim.CLI[any]) { sub.SetHelpWidth(60) },
+			wantErr: "bang sub: SetHelpWidth: must be called on the top-level CLI",
+		},
+		{
+			name:    "SetColor",
+			setter:  func(sub *clim.CLI[any]) { sub.SetColor(clim.ColorNever, nil) },
+			wantErr: "bang sub: SetColor: must be called on the top-level CLI",
+		},
+		{
+			name:    "SetReportAll",
+			setter:  func(sub *clim.CLI[any]) { sub.SetReportAll(true) },
+			wantErr: "bang sub: SetReportAll: must be called on the top-level CLI",
+		},
+		{
+			name:    "SetResponseFiles",
+			setter:  func(sub *clim.CLI[any]) { sub.SetResponseFiles(true) },
+			wantErr: "bang sub: SetResponseFiles: must be called on the top-level CLI",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
@@ -0,0 +1,90 @@
+// This file contains the support for hidden and deprecated flags and
+// subcommands.
+
+package clim
+
+import (
+	"fmt"
+	"io"
+)
+
+// SetHidden hides (or shows) cli in the help of its parent. A hidden
+// subcommand can still be invoked.
+// For a hidden flag, see [Flag.Hidden].
+func (cli *CLI[T]) SetHidden(hidden bool) {
+	cli.hidden = hidden
+}
+
+// SetDeprecated marks cli as deprecated. The subcommand still works, but each
+// invocation writes a warning containing 'message' (see
+// [CLI.SetWarningOutput]). If 'replacement' is the name of a sibling
+// subcommand, the invocation is forwarded to it.
+// For a deprecated flag, see [Flag.Deprecated].
+func (cli *CLI[T]) SetDeprecated(message string, replacement string) {
+	cli.deprecated = message
+	cli.replacedBy = replacement
+}
+
+// SetWarningOutput sets the destination of the warnings written by [CLI.Parse]
+// when a deprecated flag or subcommand is used, for example os.Stderr.
+// If not set, there are no warnings.
+func (cli *CLI[T]) SetWarningOutput(out io.Writer) {
+	cli.rootOnly("SetWarningOutput")
+	cli.warnOut = out
+}
+
+// root returns the top-level CLI of the tree containing cli.
+func (cli *CLI[T]) root() *CLI[T] {
+	node := cli
+	for node.parent != nil {
+		node = node.parent
+	}
+	return node
+}
+
+// warn writes a warning to the warning output of the tree, if any.
+func (cli *CLI[T]) warn(format string, a ...any) {
+	out := cli.root().warnOut
+	if out == nil {
+		return
+	}
+	fmt.Fprintf(out, "warning: "+format+"\n", a...)
+}
+
+// forwardFlag writes a deprecation warning for 'flag' and returns the flag
+// to parse instead: the replacement, if any, otherwise 'flag' itself.
+func (cli *CLI[T]) forwardFlag(flag *Flag) (*Flag, error) {
+	if flag.ReplacedBy == "" {
+		cli.warn("flag \"--%s\" is deprecated: %s", flag.Long, flag.Deprecated)
+		return flag, nil
+	}
+	replacement := cli.long2flag[flag.ReplacedBy]
+	if replacement == nil {
+		return nil, newDefinitionError(
+			"%s: flag \"--%s\": replacement flag \"--%s\" not defined",
+			cli.rootToHere, flag.Long, flag.ReplacedBy)
+	}
+	cli.warn("flag \"--%s\" is deprecated: %s; use \"--%s\" instead",
+		flag.Long, flag.Deprecated, replacement.Long)
+	return replacement, nil
+}
+
+// forwardCLI writes a deprecation warning for the subcommand 'sub' of cli and
+// returns the subcommand to parse instead: the replacement, if any, otherwise
+// 'sub' itself.
+func (cli *CLI[T]) forwardCLI(sub *CLI[T]) (*CLI[T], error) {
+	if sub.replacedBy == "" {
+		cli.warn("command %q is deprecated: %s", sub.rootToHere, sub.deprecated)
+		return sub, nil
+	}
+	for _, replacement := range cli.subCLIs {
+		if replacement.name == sub.replacedBy {
+			cli.warn("command %q is deprecated: %s; use %q instead",
+				sub.rootToHere, sub.deprecated, replacement.rootToHere)
+			return replacement, nil
+		}
+	}
+	return nil, newDefinitionError(
+		"%s: command %q: replacement command %q not defined",
+		cli.rootToHere, sub.name, sub.replacedBy)
+}
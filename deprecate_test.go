@@ -0,0 +1,228 @@
+package clim_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestHiddenFlagsAndSubcommands(t *testing.T) {
+	var count, secret int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(
+		&clim.Flag{Value: clim.Int(&count, 1), Long: "count", Help: "How many"},
+		&clim.Flag{
+			Value: clim.Int(&secret, 2), Long: "a-very-long-secret",
+			Help: "Secret", Hidden: true,
+		})
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub[any](cli, "sub", "I am a subcommand", nil)
+	rosina.AssertNoError(t, err)
+	hidden, err := clim.NewSub[any](cli, "a-very-long-hidden", "Hidden", nil)
+	rosina.AssertNoError(t, err)
+	hidden.SetHidden(true)
+
+	want := `bang -- bang head
+
+Usage: bang [options] <command>
+
+Commands:
+
+ sub     I am a subcommand
+
+Options:
+
+ --count COUNT    How many (default: 1)
+
+ -h, --help       Print this help and exit
+`
+	_, err = cli.Parse([]string{"-h"})
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+
+	// Hidden does not mean disabled.
+	_, err = cli.Parse([]string{"--a-very-long-secret=3", "a-very-long-hidden"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, secret, 3, "secret")
+}
+
+func TestHiddenFlagGroup(t *testing.T) {
+	var secret int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	secretFlag := &clim.Flag{
+		Value: clim.Int(&secret, 2), Long: "secret", Hidden: true,
+	}
+	err = cli.AddFlags(secretFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlagGroup("Secrets", secretFlag)
+	rosina.AssertNoError(t, err)
+
+	want := `bang -- bang head
+
+Usage: bang [options]
+
+Options:
+
+ -h, --help    Print this help and exit
+`
+	_, err = cli.Parse([]string{"-h"})
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
+
+func TestNoWarningsByDefault(t *testing.T) {
+	var old int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{
+		Value: clim.Int(&old, 0), Long: "old", Deprecated: "will be removed in v2",
+	})
+	rosina.AssertNoError(t, err)
+	readReset := rosina.InterceptOutput(t, &os.Stderr)
+
+	_, err = cli.Parse([]string{"--old=1"})
+
+	stderr := readReset()
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, stderr, "", "stderr")
+}
+
+func TestDeprecatedFlag(t *testing.T) {
+	var level, old, count int
+	var warnings strings.Builder
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetWarningOutput(&warnings)
+	err = cli.AddFlags(
+		&clim.Flag{Value: clim.Int(&level, 0), Long: "level", Help: "Level"},
+		&clim.Flag{
+			Value: clim.Int(&old, 0), Long: "old", Help: "Old",
+			Deprecated: "will be removed in v2",
+		},
+		&clim.Flag{
+			Short: "c", Long: "cnt",
+			Deprecated: "renamed", ReplacedBy: "count", Hidden: true,
+		},
+		&clim.Flag{Value: clim.Int(&count, 0), Long: "count", Required: true},
+	)
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--old=1", "-c", "2", "--level", "3"})
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, old, 1, "old")
+	rosina.AssertEqual(t, count, 2, "count")
+	rosina.AssertEqual(t, level, 3, "level")
+	rosina.AssertEqual(t, cli.FlagSource("count"), clim.SourceCommandLine,
+		"source of count")
+	wantWarnings := `warning: flag "--old" is deprecated: will be removed in v2
+warning: flag "--cnt" is deprecated: renamed; use "--count" instead
+`
+	rosina.AssertTextEqual(t, warnings.String(), wantWarnings, "warnings")
+
+	want := `bang -- bang head
+
+Usage: bang --count COUNT [options]
+
+Options:
+
+ --level LEVEL    Level (default: 0)
+ --old OLD        Old (default: 0) (deprecated)
+ --count COUNT     (required)
+
+ -h, --help       Print this help and exit
+`
+	_, err = cli.Parse([]string{"-h"})
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
+
+func TestDeprecatedFlagFailure(t *testing.T) {
+	type testCase struct {
+		name    string
+		flag    *clim.Flag
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetWarningOutput(&strings.Builder{})
+
+		err = cli.AddFlags(tc.flag)
+		if err == nil {
+			_, err = cli.Parse([]string{"--old=1"})
+		}
+
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:    "missing value",
+			flag:    &clim.Flag{Long: "old"},
+			wantErr: `long flag name "old": missing value`,
+		},
+		{
+			name:    "ReplacedBy without Deprecated",
+			flag:    &clim.Flag{Long: "old", ReplacedBy: "new"},
+			wantErr: `long flag name "old": ReplacedBy requires Deprecated`,
+		},
+		{
+			name: "replacement not defined",
+			flag: &clim.Flag{
+				Long: "old", Deprecated: "renamed", ReplacedBy: "new",
+			},
+			wantErr: `bang: flag "--old": replacement flag "--new" not defined`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestDeprecatedSubcommand(t *testing.T) {
+	var calls []string
+	var warnings strings.Builder
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetWarningOutput(&warnings)
+	newSub := func(name string) *clim.CLI[any] {
+		sub, err := clim.NewSub[any](cli, name, name,
+			func(uctx any) error {
+				calls = append(calls, name)
+				return nil
+			})
+		rosina.AssertNoError(t, err)
+		return sub
+	}
+	newSub("remove")
+	rm := newSub("rm")
+	rm.SetDeprecated("use the long name", "remove")
+	del := newSub("delete")
+	del.SetDeprecated("will be removed in v2", "")
+	bad := newSub("bad")
+	bad.SetDeprecated("oops", "missing")
+
+	for _, args := range [][]string{{"rm"}, {"delete"}} {
+		action, err := cli.Parse(args)
+		rosina.AssertNoError(t, err)
+		err = action(nil)
+		rosina.AssertNoError(t, err)
+	}
+	rosina.AssertDeepEqual(t, calls, []string{"remove", "delete"}, "calls")
+	wantWarnings := `warning: command "bang rm" is deprecated: use the long name; use "bang remove" instead
+warning: command "bang delete" is deprecated: will be removed in v2
+`
+	rosina.AssertTextEqual(t, warnings.String(), wantWarnings, "warnings")
+
+	_, err = cli.Parse([]string{"bad"})
+	rosina.AssertErrorContains(t, err,
+		`bang: command "bad": replacement command "missing" not defined`)
+}
@@ -33,7 +33,7 @@ func TestFlatCliHelp(t *testing.T) {
  Long description.
  Could be multi-line.
 
-Usage: flat [options]
+Usage: flat [--doors N[,N,..] | --windows N[,N,..] | --floors F[,F,..]] [options]
 
 Examples:
 
@@ -52,6 +52,10 @@ Options:
 
  -h, --help            Print this help and exit
 
+Constraints:
+
+ at most one of: --doors, --windows, --floors
+
  For more information, see https://www.example.org/
 `
 	err := mainErr([]string{"-h"})
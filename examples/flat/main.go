@@ -91,6 +91,12 @@ Could be multi-line.`)
 		return err
 	}
 
+	if err := cli.AddConstraints(
+		clim.AtMostOne("doors", "windows", "floors"),
+	); err != nil {
+		return err
+	}
+
 	action, err := cli.Parse(args)
 	if err != nil {
 		return err
@@ -100,12 +106,6 @@ Could be multi-line.`)
 }
 
 func (args *Application) run(uctx int) error {
-	// Validation
-	if clim.CountTrue(args.doors != nil, args.windows != nil,
-		args.floors != nil) > 1 {
-		return clim.NewParseError("only one of doors, windows, floors can be specified")
-	}
-
 	for i := range args.count {
 		fmt.Println(i+1, "flatten against", args.wall)
 	}
@@ -20,6 +20,8 @@ import (
 )
 
 func TestMain(m *testing.M) {
+	// Hermetic help: without COLUMNS, it is wrapped at the default width.
+	os.Unsetenv("COLUMNS")
 	os.Exit(testscript.RunMain(m, map[string]func() int{
 		"flat": flat.MainInt,
 	}))
@@ -0,0 +1,45 @@
+// The reference documentation in directory testdata/reference is generated
+// from the command tree. This test fails if it is out of date, so that CI
+// catches a change to the CLI without a corresponding change to the docs.
+// To regenerate it, run:
+//
+//	go test -run TestReferenceDocs -update
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marco-m/rosina"
+)
+
+var update = flag.Bool("update", false, "update the reference docs")
+
+func TestReferenceDocs(t *testing.T) {
+	cli, err := newCLI()
+	rosina.AssertNoError(t, err)
+
+	wantDir := filepath.Join("testdata", "reference")
+	if *update {
+		rosina.AssertNoError(t, os.MkdirAll(wantDir, 0o755))
+		rosina.AssertNoError(t, cli.WriteMarkdown(wantDir))
+	}
+
+	haveDir := t.TempDir()
+	err = cli.WriteMarkdown(haveDir)
+	rosina.AssertNoError(t, err)
+
+	entries, err := os.ReadDir(haveDir)
+	rosina.AssertNoError(t, err)
+	wantEntries, err := os.ReadDir(wantDir)
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, len(entries), len(wantEntries), "number of files")
+	for _, entry := range entries {
+		rosina.AssertFileEqualsFile(t,
+			filepath.Join(haveDir, entry.Name()),
+			filepath.Join(wantDir, entry.Name()))
+	}
+}
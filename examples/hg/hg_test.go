@@ -4,9 +4,24 @@ import (
 	"os"
 	"testing"
 
+	"github.com/marco-m/clim"
 	"github.com/marco-m/rosina"
 )
 
+// TestMain makes the help tests hermetic: without COLUMNS, the help is wrapped
+// at the default width.
+func TestMain(m *testing.M) {
+	os.Unsetenv("COLUMNS")
+	os.Exit(m.Run())
+}
+
+func TestCLIDefinition(t *testing.T) {
+	cli, err := newCLI()
+	rosina.AssertNoError(t, err)
+
+	rosina.AssertNoError(t, cli.Validate())
+}
+
 func TestClone(t *testing.T) {
 	want := `hello from CloneCmd Run
 &main.cloneCmd{noUpdate:false, updateRev:""}
@@ -58,3 +73,53 @@ func TestOutgoing(t *testing.T) {
 	out := readReset()
 	rosina.AssertEqual(t, out, want, "stdout")
 }
+
+func TestHelpCommand(t *testing.T) {
+	type testCase struct {
+		name string
+		args []string
+		want string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		err := mainErr(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		rosina.AssertTextEqual(t, err.Error(), tc.want, "help message")
+	}
+
+	testCases := []testCase{
+		{
+			name: "command",
+			args: []string{"help", "init"},
+			want: `hg init -- create a new repository in the given directory
+
+Usage: hg init [options]
+
+Options:
+
+ --remotecmd CMD    specify hg command to run on the remote side
+ --mq               operate on patch repository (default: false)
+
+ -h, --help         Print this help and exit
+`,
+		},
+		{
+			name: "topic",
+			args: []string{"help", "urls"},
+			want: `hg help urls -- URL Paths
+
+Valid URLs are of the form:
+
+  local/filesystem/path[#revision]
+  file://local/filesystem/path[#revision]
+  http://[user[:pass]@]host[:port]/[path][#revision]
+  ssh://[user@]host[:port]/[path][#revision]
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
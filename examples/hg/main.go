@@ -33,42 +33,64 @@ func mainInt() int {
 type user struct{}
 
 func mainErr(args []string) error {
-	cli, err := clim.NewTop[user]("hg", "Mercurial Distributed SCM", nil)
+	cli, err := newCLI()
 	if err != nil {
 		return err
 	}
 
-	clonecli, err := newCloneCLI(cli)
+	action, err := cli.Parse(args)
 	if err != nil {
 		return err
 	}
+
+	uctx := user{}
+	return action(uctx)
+}
+
+func newCLI() (*clim.CLI[user], error) {
+	cli, err := clim.NewTop[user]("hg", "Mercurial Distributed SCM", nil)
+	if err != nil {
+		return nil, err
+	}
+	cli.SetHelpCommand(true)
+	if err := cli.AddHelpTopic("urls", "URL Paths", urlsTopic); err != nil {
+		return nil, err
+	}
+
+	clonecli, err := newCloneCLI(cli)
+	if err != nil {
+		return nil, err
+	}
 	initcli, err := newInitCLI(cli)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := cli.AddGroup("Repository creation",
 		clonecli, initcli); err != nil {
-		return err
+		return nil, err
 	}
 
 	incomingcli, err := newIncomingCLI(cli)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	outgoingcli, err := newOutgoingCLI(cli)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := cli.AddGroup("Remote repository management",
 		incomingcli, outgoingcli); err != nil {
-		return err
+		return nil, err
 	}
 
-	action, err := cli.Parse(args)
-	if err != nil {
-		return err
-	}
-
-	uctx := user{}
-	return action(uctx)
+	return cli, nil
 }
+
+const urlsTopic = `
+Valid URLs are of the form:
+
+  local/filesystem/path[#revision]
+  file://local/filesystem/path[#revision]
+  http://[user[:pass]@]host[:port]/[path][#revision]
+  ssh://[user@]host[:port]/[path][#revision]
+`
@@ -0,0 +1,145 @@
+// This file contains the mapping from errors to process exit codes.
+
+package clim
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Exit codes used by [ExitCode]. The codes from 64 to 78 are the ones of
+// sysexits.h, common on Unix systems.
+const (
+	ExitOK      = 0 // Success, or help requested.
+	ExitFailure = 1 // Generic failure.
+	ExitParse   = 2 // Command-line parse error (see [ErrParse]).
+	//
+	ExitUsage       = 64 // The command was used incorrectly.
+	ExitDataErr     = 65 // The input data was incorrect.
+	ExitNoInput     = 66 // An input file did not exist or was not readable.
+	ExitNoUser      = 67 // The user specified did not exist.
+	ExitNoHost      = 68 // The host specified did not exist.
+	ExitUnavailable = 69 // A service is unavailable.
+	ExitSoftware    = 70 // An internal software error has been detected.
+	ExitOSErr       = 71 // An operating system error has been detected.
+	ExitOSFile      = 72 // A system file did not exist or could not be opened.
+	ExitCantCreat   = 73 // A (user specified) output file cannot be created.
+	ExitIOErr       = 74 // An error occurred while doing I/O on some file.
+	ExitTempFail    = 75 // Temporary failure, the user is invited to retry.
+	ExitProtocol    = 76 // The remote system returned something invalid.
+	ExitNoPerm      = 77 // The user did not have sufficient permission.
+	ExitConfig      = 78 // Something was found in an unconfigured state.
+	//
+	ExitInterrupted = 130 // Interrupted by the user (128 + SIGINT).
+)
+
+// ExitCoder is implemented by an error that carries its own exit code.
+// [ExitCode] looks for it with errors.As, thus it can be wrapped.
+// See also [Exit].
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// Exit returns an error that wraps 'err' and carries exit code 'code', for
+// [ExitCode]. If 'err' is nil, the error has an empty message and ExitCode
+// prints nothing. For example, from an action:
+//
+//	if len(todo) == 0 {
+//	    return clim.Exit(3, errors.New("nothing to do"))
+//	}
+func Exit(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitError is the error returned by [Exit].
+type exitError struct {
+	code int
+	err  error
+}
+
+// Error implements the error interface.
+func (ee *exitError) Error() string {
+	if ee.err == nil {
+		return ""
+	}
+	return ee.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (ee *exitError) Unwrap() error {
+	return ee.err
+}
+
+// ExitCode implements the [ExitCoder] interface.
+func (ee *exitError) ExitCode() int {
+	return ee.code
+}
+
+// ExitMapping maps the errors matching Err (with errors.Is) to exit code Code.
+// See [ExitCode].
+type ExitMapping struct {
+	Err  error
+	Code int
+}
+
+// ExitCode is an helper function to reduce the boilerplate in your program.
+// It calls mainErr(args), prints the help or the error (if not empty) and
+// returns an appropriate exit code, the first matching:
+//   - 0 if no error
+//   - the code of the [ExitCoder] in the error chain, if any (see [Exit])
+//   - the code of the first element of 'mappings' matching the error
+//   - 0 if help requested
+//   - 2 if command-line parse error
+//   - 1 if any other errors, including definition errors (see [ErrDefinition])
+//
+// Usage:
+//
+//	func main() {
+//	    os.Exit(clim.ExitCode(mainErr, os.Args[1:], os.Stderr))
+//	}
+//
+// To use the sysexits code for a parse error and a custom code for an error
+// of your program:
+//
+//	os.Exit(clim.ExitCode(mainErr, os.Args[1:], os.Stderr,
+//	    clim.ExitMapping{Err: clim.ErrParse, Code: clim.ExitUsage},
+//	    clim.ExitMapping{Err: ErrNotFound, Code: 3}))
+func ExitCode(mainErr func(args []string) error, args []string, out io.Writer,
+	mappings ...ExitMapping,
+) int {
+	return exitWith(mainErr(args), out, mappings)
+}
+
+// exitWith prints 'err' (if not empty) to 'out' and returns its exit code.
+// See [ExitCode].
+func exitWith(err error, out io.Writer, mappings []ExitMapping) int {
+	if err == nil {
+		return ExitOK
+	}
+	if msg := err.Error(); msg != "" {
+		fmt.Fprintln(out, msg)
+	}
+	return exitCodeOf(err, mappings)
+}
+
+// exitCodeOf returns the exit code of 'err'. See [ExitCode].
+func exitCodeOf(err error, mappings []ExitMapping) int {
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	for _, m := range mappings {
+		if errors.Is(err, m.Err) {
+			return m.Code
+		}
+	}
+	if errors.Is(err, ErrHelp) {
+		return ExitOK
+	}
+	if errors.Is(err, ErrParse) {
+		return ExitParse
+	}
+	return ExitFailure
+}
@@ -0,0 +1,150 @@
+package clim_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestExitCode(t *testing.T) {
+	type testCase struct {
+		name     string
+		args     []string
+		mappings []clim.ExitMapping
+		wantCode int
+		wantOut  string
+	}
+
+	errNotFound := errors.New("not found")
+
+	test := func(t *testing.T, tc testCase) {
+		mainErr := func(args []string) error {
+			var fail string
+			cli, err := clim.NewTop[any]("bang", "bang head",
+				func(uctx any) error {
+					switch fail {
+					case "":
+						return nil
+					case "nothing":
+						return clim.Exit(3, errors.New("nothing to do"))
+					case "silent":
+						return clim.Exit(3, nil)
+					case "wrapped":
+						return fmt.Errorf("run: %w",
+							clim.Exit(clim.ExitInterrupted, errors.New("interrupted")))
+					case "not-found":
+						return fmt.Errorf("banana: %w", errNotFound)
+					default:
+						return errors.New("boom")
+					}
+				})
+			if err != nil {
+				return err
+			}
+			if err := cli.AddFlags(&clim.Flag{
+				Value: clim.String(&fail, ""), Long: "fail", Help: "How to fail",
+			}); err != nil {
+				return err
+			}
+			action, err := cli.Parse(args)
+			if err != nil {
+				return err
+			}
+			return action(nil)
+		}
+		out := &strings.Builder{}
+
+		code := clim.ExitCode(mainErr, tc.args, out, tc.mappings...)
+
+		rosina.AssertEqual(t, code, tc.wantCode, "exit code")
+		rosina.AssertTextEqual(t, out.String(), tc.wantOut, "output")
+	}
+
+	testCases := []testCase{
+		{
+			name:     "success",
+			wantCode: 0,
+		},
+		{
+			name:     "help",
+			args:     []string{"-h"},
+			wantCode: 0,
+			wantOut: `bang -- bang head
+
+Usage: bang [options]
+
+Options:
+
+ --fail FAIL    How to fail
+
+ -h, --help     Print this help and exit
+
+`,
+		},
+		{
+			name:     "parse error",
+			args:     []string{"--banana"},
+			wantCode: 2,
+			wantOut:  "unrecognized flag \"--banana\"\n",
+		},
+		{
+			name: "parse error, mapped",
+			args: []string{"--banana"},
+			mappings: []clim.ExitMapping{
+				{Err: clim.ErrParse, Code: clim.ExitUsage},
+			},
+			wantCode: 64,
+			wantOut:  "unrecognized flag \"--banana\"\n",
+		},
+		{
+			name:     "other error",
+			args:     []string{"--fail=boom"},
+			wantCode: 1,
+			wantOut:  "boom\n",
+		},
+		{
+			name:     "exit coder",
+			args:     []string{"--fail=nothing"},
+			wantCode: 3,
+			wantOut:  "nothing to do\n",
+		},
+		{
+			name:     "exit coder, nil error prints nothing",
+			args:     []string{"--fail=silent"},
+			wantCode: 3,
+		},
+		{
+			name:     "exit coder, wrapped",
+			args:     []string{"--fail=wrapped"},
+			wantCode: 130,
+			wantOut:  "run: interrupted\n",
+		},
+		{
+			name: "exit coder wins over mapping",
+			args: []string{"--fail=nothing"},
+			mappings: []clim.ExitMapping{
+				{Err: clim.ErrParse, Code: clim.ExitUsage},
+			},
+			wantCode: 3,
+			wantOut:  "nothing to do\n",
+		},
+		{
+			name: "mapping, first match wins",
+			args: []string{"--fail=not-found"},
+			mappings: []clim.ExitMapping{
+				{Err: errNotFound, Code: 4},
+				{Err: errNotFound, Code: 5},
+			},
+			wantCode: 4,
+			wantOut:  "banana: not found\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
@@ -2,153 +2,367 @@ package clim
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 )
 
-func (cli *CLI[T]) usage() error {
-	var bld strings.Builder
+// defaultHelpWidth is the width of the help when not set with
+// [CLI.SetHelpWidth] and not available from the environment.
+const defaultHelpWidth = 80
+
+// SetHelpWidth sets the width, in columns, to which the help is wrapped.
+// A width of 0 (the default) means to use environment variable COLUMNS or,
+// if not set, 80 columns. A negative width disables wrapping.
+//
+// The text in the second column (the help of options, subcommands and
+// positional arguments) is wrapped with a hanging indentation, so that the
+// continuation lines stay aligned with the second column.
+func (cli *CLI[T]) SetHelpWidth(width int) {
+	cli.rootOnly("SetHelpWidth")
+	cli.helpWidth = width
+}
+
+// SetHelpMaxColumn sets the maximum column at which the help of an option
+// begins. An option whose first column (short and long names, label) would push
+// the help beyond 'column' is put on its own line, with its help on the next
+// line. A column of 0 (the default) means no limit.
+func (cli *CLI[T]) SetHelpMaxColumn(column int) {
+	cli.rootOnly("SetHelpMaxColumn")
+	cli.helpMaxCol = column
+}
 
-	// Calculate the max width of the first column of commands.
-	maxColWidth := 0
-	for _, p := range cli.subCLIs {
-		fmt.Fprintf(&bld, " %s", p.name)
-		maxColWidth = max(maxColWidth, bld.Len())
-		bld.Reset()
+// width returns the width to which the help is wrapped, or a value <= 0 if
+// wrapping is disabled.
+func (cli *CLI[T]) width() int {
+	width := cli.root().helpWidth
+	if width != 0 {
+		return width
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
 	}
+	return defaultHelpWidth
+}
 
-	fmt.Fprintf(&bld, "%s -- %s\n\n", cli.rootToHere, cli.oneline)
+// Help is the data model of the help of a command. It is built by clim and
+// passed to a [HelpRenderer]; see [CLI.SetHelpRenderer].
+// Hidden flags and subcommands are not part of it.
+type Help struct {
+	Name        string // Name of the command, for example "clone".
+	Path        string // Path from the top-level command, for example "hg clone".
+	Oneline     string // One-line description.
+	Description string // Description, can be multi-line. Optional.
+	// Synopsis lines, each without the "Usage: " prefix.
+	Synopsis []string
+	Examples string // Examples, can be multi-line. Optional.
+	// All the subcommands, in order of creation.
+	Commands []HelpCommand
+	// The groups of subcommands, if any. See [CLI.AddGroup].
+	Groups []HelpCommandGroup
+	// HelpCommand is true if the "help" subcommand is available.
+	// See [CLI.SetHelpCommand].
+	HelpCommand bool
+	// The help topics, if any. See [CLI.AddHelpTopic].
+	Topics []HelpCommand
+	// The flags not belonging to any group, in order of addition.
+	Flags []HelpFlag
+	// The groups of flags, if any. See [CLI.AddFlagGroup].
+	FlagGroups  []HelpFlagGroup
+	Constraints []string // See [CLI.AddConstraints].
+	Positionals []HelpPosArg
+	Footer      string // Footer, can be multi-line. Optional.
+	// Wrapping width, or <= 0 if wrapping is disabled. See [CLI.SetHelpWidth].
+	Width int
+	// Max column of the help of an option, or 0. See [CLI.SetHelpMaxColumn].
+	MaxColumn int
+	// Full is true for the full help, false for the brief help, which has
+	// no Description, Examples, Footer and LongHelp. See [CLI.SetShortHelp].
+	Full bool
+	// Color is true if the help should be styled with ANSI escape sequences.
+	// See [CLI.SetColor].
+	Color bool
+}
 
-	if cli.description != "" {
-		for _, line := range strings.Split(cli.description, "\n") {
-			fmt.Fprintf(&bld, " %s\n", line)
-		}
-		fmt.Fprintln(&bld)
+// HelpCommand is a subcommand in [Help].
+type HelpCommand struct {
+	Name    string
+	Oneline string
+}
+
+// HelpCommandGroup is a group of subcommands in [Help].
+type HelpCommandGroup struct {
+	Name     string
+	Commands []HelpCommand
+}
+
+// HelpFlag is a flag in [Help].
+type HelpFlag struct {
+	Short      string
+	Long       string
+	Label      string
+	Help       string
+	LongHelp   string // Only in the full help.
+	Default    string // Empty if the flag is required.
+	Required   bool
+	Env        string
+	Deprecated bool
+}
+
+// HelpFlagGroup is a group of flags in [Help].
+type HelpFlagGroup struct {
+	Name  string
+	Flags []HelpFlag
+}
+
+// HelpPosArg is a positional argument in [Help].
+type HelpPosArg struct {
+	Name string
+	Help string
+}
+
+// Names returns the flag names and label, as in "-c, --count N".
+func (hf HelpFlag) Names() string {
+	var bld strings.Builder
+	if hf.Short != "" {
+		fmt.Fprintf(&bld, "-%s, ", hf.Short)
 	}
+	fmt.Fprintf(&bld, "--%s %s", hf.Long, hf.Label)
+	return bld.String()
+}
 
-	fmt.Fprintf(&bld, "Usage: %s ", cli.rootToHere)
-	if len(cli.subCLIs) > 0 {
-		fmt.Fprintf(&bld, "<command> ")
+// Notes returns the help text followed by the annotations, as in
+// "How many times (default: 3)".
+func (hf HelpFlag) Notes() string {
+	var bld strings.Builder
+	fmt.Fprintf(&bld, "%s", hf.Help)
+	if hf.Default != "" {
+		fmt.Fprintf(&bld, " (default: %s)", hf.Default)
 	}
-	fmt.Fprintf(&bld, "[options]")
-	for _, pair := range cli.pairs {
-		fmt.Fprintf(&bld, " %s", pair.Name)
+	if hf.Required {
+		fmt.Fprintf(&bld, " (required)")
 	}
-	fmt.Fprintf(&bld, "\n\n")
-
-	if cli.examples != "" {
-		fmt.Fprintf(&bld, "Examples:\n\n")
-		for _, line := range strings.Split(cli.examples, "\n") {
-			if line != "" {
-				fmt.Fprintf(&bld, " %s\n", line)
-			} else {
-				fmt.Fprintln(&bld)
-			}
-		}
-		fmt.Fprintln(&bld)
+	if hf.Env != "" {
+		fmt.Fprintf(&bld, " (env: %s)", hf.Env)
 	}
-
-	if len(cli.groups) > 0 {
-		fmt.Fprintf(&bld, "available commands:\n\n")
-	} else if len(cli.subCLIs) > 0 {
-		fmt.Fprintf(&bld, "Commands:\n\n")
+	if hf.Deprecated {
+		fmt.Fprintf(&bld, " (deprecated)")
 	}
+	return bld.String()
+}
 
-	const gutter = 4
-	width := maxColWidth + gutter
-
-	// Render the commands, per group.
-	if len(cli.groups) > 0 {
-		for _, group := range cli.groups {
-			fmt.Fprintf(&bld, "%s:\n\n", group.name)
-			printSomeSubCommands(&bld, width, group.clis)
-		}
-	} else if len(cli.subCLIs) > 0 {
-		printSomeSubCommands(&bld, width, cli.subCLIs)
-	}
+// SetHelpRenderer sets the renderer of the help. If not set, the help is
+// rendered by [TextRenderer].
+func (cli *CLI[T]) SetHelpRenderer(renderer HelpRenderer) {
+	cli.rootOnly("SetHelpRenderer")
+	cli.renderer = renderer
+}
 
-	cli.printOptions(&bld)
+// SetShortHelp enables (or disables) the brief help: with it, -h prints only
+// the synopsis, the subcommands and the options with their one-line help,
+// while --help prints the full help, with description, examples, footer and
+// [Flag.LongHelp]. Without it (the default), both print the full help.
+func (cli *CLI[T]) SetShortHelp(enable bool) {
+	cli.rootOnly("SetShortHelp")
+	cli.shortHelp = enable
+}
 
-	cli.printPosArgs(&bld)
+// usage returns the full help of cli, rendered, as an error that unwraps to
+// [ErrHelp].
+func (cli *CLI[T]) usage() error {
+	return cli.render(cli.help())
+}
 
-	if cli.footer != "" {
-		fmt.Fprintln(&bld)
-		for _, line := range strings.Split(cli.footer, "\n") {
-			fmt.Fprintf(&bld, " %s\n", line)
+// briefUsage is like [CLI.usage], for the brief help.
+func (cli *CLI[T]) briefUsage() error {
+	help := cli.help()
+	help.Full = false
+	help.Description = ""
+	help.Examples = ""
+	help.Footer = ""
+	for i := range help.Flags {
+		help.Flags[i].LongHelp = ""
+	}
+	for _, group := range help.FlagGroups {
+		for i := range group.Flags {
+			group.Flags[i].LongHelp = ""
 		}
 	}
+	return cli.render(help)
+}
 
+// render renders 'help' with the configured renderer, as an error that
+// unwraps to [ErrHelp].
+func (cli *CLI[T]) render(help *Help) error {
+	var renderer HelpRenderer = TextRenderer{}
+	if root := cli.root(); root.renderer != nil {
+		renderer = root.renderer
+	}
+	var bld strings.Builder
+	if err := renderer.Render(&bld, help); err != nil {
+		return fmt.Errorf("clim: rendering help of %s: %w", cli.rootToHere, err)
+	}
 	return newHelpError("%s", bld.String())
 }
 
-func (cli *CLI[T]) printOptions(bld *strings.Builder) {
+// help returns the data model of the help of cli.
+func (cli *CLI[T]) help() *Help {
+	help := &Help{
+		Name:        cli.name,
+		Path:        cli.rootToHere,
+		Oneline:     cli.oneline,
+		Description: cli.description,
+		Examples:    cli.examples,
+		Footer:      cli.footer,
+		Width:       cli.width(),
+		MaxColumn:   cli.root().helpMaxCol,
+		Full:        true,
+		Color:       cli.color(),
+	}
+
+	help.Synopsis = cli.synopsis()
+
+	for _, sub := range cli.subCLIs {
+		if !sub.hidden {
+			help.Commands = append(help.Commands, sub.helpCommand())
+		}
+	}
+	for _, group := range cli.groups {
+		hg := HelpCommandGroup{Name: group.name}
+		for _, sub := range group.clis {
+			if !sub.hidden {
+				hg.Commands = append(hg.Commands, sub.helpCommand())
+			}
+		}
+		if len(hg.Commands) > 0 {
+			help.Groups = append(help.Groups, hg)
+		}
+	}
+	help.HelpCommand = cli.hasHelpCommand()
+	help.Topics = cli.helpTopics()
+
 	// Do not sort the flags! The sorting breaks any semantic meaning that the
 	// manual ordering had.
-
-	// Calculate the max width of the first column.
-	lines := make([]string, 0, len(cli.orderedFlags)+1)
-	var tmp strings.Builder
-	maxColWidth := 0
 	for _, long := range cli.orderedFlags {
 		flag := cli.long2flag[long]
-		fmt.Fprintf(&tmp, " ")
-		if flag.Short != "" {
-			fmt.Fprintf(&tmp, "-%s, ", flag.Short)
+		if flag.group == "" && !flag.Hidden {
+			help.Flags = append(help.Flags, flag.help())
 		}
-		fmt.Fprintf(&tmp, "--%s %s", flag.Long, flag.Label)
-		lines = append(lines, tmp.String())
-		maxColWidth = max(maxColWidth, tmp.Len())
-		tmp.Reset()
-	}
-	// Same for -h
-	fmt.Fprint(&tmp, " -h, --help")
-	maxColWidth = max(maxColWidth, tmp.Len())
-	tmp.Reset()
-
-	// Third pass, add the second column.
-	const gutter = 4
-	fmt.Fprintf(bld, "Options:\n\n")
-	for i, long := range cli.orderedFlags {
-		flag := cli.long2flag[long]
-		fmt.Fprintf(bld, "%-*s%s", maxColWidth+gutter, lines[i], flag.Help)
-		if flag.defValue != "" && !flag.Required {
-			fmt.Fprintf(bld, " (default: %s)", flag.defValue)
+	}
+	for _, group := range cli.flagGroups {
+		hg := HelpFlagGroup{Name: group}
+		for _, long := range cli.orderedFlags {
+			flag := cli.long2flag[long]
+			if flag.group == group && !flag.Hidden {
+				hg.Flags = append(hg.Flags, flag.help())
+			}
 		}
-		if flag.Required {
-			fmt.Fprintf(bld, " (required)")
+		if len(hg.Flags) > 0 {
+			help.FlagGroups = append(help.FlagGroups, hg)
 		}
-		fmt.Fprintf(bld, "\n")
 	}
-	if len(cli.orderedFlags) > 0 {
-		fmt.Fprintf(bld, "\n")
+
+	for _, c := range cli.constraints {
+		help.Constraints = append(help.Constraints, c.String())
+	}
+	for _, pair := range cli.pairs {
+		help.Positionals = append(help.Positionals,
+			HelpPosArg{Name: pair.Name, Help: pair.Help})
 	}
 
-	fmt.Fprintf(bld, "%-*s%s", maxColWidth+gutter,
-		" -h, --help", "Print this help and exit\n")
+	return help
 }
 
-func (cli *CLI[T]) printPosArgs(bld *strings.Builder) {
-	if len(cli.pairs) == 0 {
-		return
+// SetSynopsis replaces the generated synopsis with 'lines', for the cases
+// where the generated one cannot express the usage. Each line is what follows
+// the command path, for example "[options] SOURCE [DEST]".
+func (cli *CLI[T]) SetSynopsis(lines ...string) {
+	cli.synopsisLines = lines
+}
+
+// synopsis returns the synopsis lines of cli. Unless set with
+// [CLI.SetSynopsis], there is one line, with:
+//   - the required flags;
+//   - the flags of each ExactlyOne constraint, as (--a A | --b B);
+//   - the flags of each AtMostOne constraint, as [--a A | --b B];
+//   - "[options]", followed by "<command>" if cli has subcommands;
+//   - the positional arguments, whose names mark them as optional
+//     ([FILE]) or repeated (FILE...).
+func (cli *CLI[T]) synopsis() []string {
+	if len(cli.synopsisLines) > 0 {
+		lines := make([]string, 0, len(cli.synopsisLines))
+		for _, line := range cli.synopsisLines {
+			lines = append(lines, cli.rootToHere+" "+line)
+		}
+		return lines
 	}
 
-	// First pass, calculate the max width of the first column.
-	maxColWidth := 0
-	for _, pair := range cli.pairs {
-		maxColWidth = max(maxColWidth, len(pair.Name))
+	parts := []string{cli.rootToHere}
+
+	exclusive := map[string]bool{}
+	var alternatives []string
+	for _, c := range cli.constraints {
+		open, close := "", ""
+		switch c.kind {
+		case exactlyOne:
+			open, close = "(", ")"
+		case atMostOne:
+			open, close = "[", "]"
+		default:
+			continue
+		}
+		var alts []string
+		for _, long := range c.longs {
+			exclusive[long] = true
+			alts = append(alts, cli.long2flag[long].usage())
+		}
+		alternatives = append(alternatives,
+			open+strings.Join(alts, " | ")+close)
 	}
 
-	// Second pass, consider the second column.
-	fmt.Fprintln(bld)
-	const gutter = 6
-	fmt.Fprintf(bld, "Positional arguments:\n\n")
+	for _, long := range cli.orderedFlags {
+		flag := cli.long2flag[long]
+		if flag.Required && !flag.Hidden && !exclusive[long] {
+			parts = append(parts, flag.usage())
+		}
+	}
+	parts = append(parts, alternatives...)
+	parts = append(parts, "[options]")
+	// The flags of a command must precede its subcommand, see parse.
+	if len(cli.subCLIs) > 0 {
+		parts = append(parts, "<command>")
+	}
 	for _, pair := range cli.pairs {
-		fmt.Fprintf(bld, " %-*s%s\n", maxColWidth+gutter, pair.Name, pair.Help)
+		parts = append(parts, pair.Name)
 	}
+	return []string{strings.Join(parts, " ")}
 }
 
-func printSomeSubCommands[T any](bld *strings.Builder, width int, subclis []*CLI[T]) {
-	for _, cmd := range subclis {
-		fmt.Fprintf(bld, " %-*s%s\n", width, cmd.name, cmd.oneline)
+// usage returns the flag as it appears in the synopsis, as in "--count N".
+func (flag *Flag) usage() string {
+	if flag.Label == "" {
+		return "--" + flag.Long
+	}
+	return "--" + flag.Long + " " + flag.Label
+}
+
+func (cli *CLI[T]) helpCommand() HelpCommand {
+	return HelpCommand{Name: cli.name, Oneline: cli.oneline}
+}
+
+func (flag *Flag) help() HelpFlag {
+	hf := HelpFlag{
+		Short:      flag.Short,
+		Long:       flag.Long,
+		Label:      flag.Label,
+		Help:       flag.Help,
+		LongHelp:   flag.LongHelp,
+		Required:   flag.Required,
+		Env:        flag.Env,
+		Deprecated: flag.Deprecated != "",
+	}
+	if !flag.Required {
+		hf.Default = flag.defValue
 	}
-	fmt.Fprintln(bld)
+	return hf
 }
@@ -81,7 +81,7 @@ func TestHelpOfRequiredFlag(t *testing.T) {
 
 	want := `bang -- bang head
 
-Usage: bang [options]
+Usage: bang --count COUNT [options]
 
 Options:
 
@@ -155,7 +155,7 @@ func TestHelpSubCommandsOneLevel(t *testing.T) {
 
 	want := `bang -- bangs head against wall
 
-Usage: bang <command> [options]
+Usage: bang [options] <command>
 
 Commands:
 
@@ -213,7 +213,7 @@ func TestHelpSubCommandsGroup(t *testing.T) {
 
 	want := `bang -- bangs head against wall
 
-Usage: bang <command> [options]
+Usage: bang [options] <command>
 
 available commands:
 
@@ -235,6 +235,39 @@ Options:
 	rosina.AssertDeepEqual(t, err.Error(), want, "error text")
 }
 
+func TestHelpOfGroupWithOnlyHiddenCommands(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bangs head against wall", nil)
+	rosina.AssertNoError(t, err)
+	subCliA, err := clim.NewSub[any](cli, "sub-A", "I am subcommand A", nil)
+	rosina.AssertNoError(t, err)
+	subCliB, err := clim.NewSub[any](cli, "sub-B", "I am subcommand B", nil)
+	rosina.AssertNoError(t, err)
+	subCliB.SetHidden(true)
+	err = cli.AddGroup("group 1", subCliA)
+	rosina.AssertNoError(t, err)
+	err = cli.AddGroup("group 2", subCliB)
+	rosina.AssertNoError(t, err)
+
+	want := `bang -- bangs head against wall
+
+Usage: bang [options] <command>
+
+available commands:
+
+group 1:
+
+ sub-A     I am subcommand A
+
+Options:
+
+ -h, --help    Print this help and exit
+`
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
+
 func TestPosArgsRequiredHelpTake1(t *testing.T) {
 	want := `bang -- bang head
 
@@ -293,3 +326,471 @@ Positional arguments:
 // 	rosina.AssertErrorIs(t, err, clim.ErrHelp)
 // 	rosina.AssertDeepEqual(t, err.Error(), want, "help message")
 // }
+
+func TestHelpOfFlagGroups(t *testing.T) {
+	var verbose, json bool
+	var output, proxy string
+	var timeout int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+
+	outputFlag := &clim.Flag{
+		Value: clim.String(&output, ""), Short: "o", Long: "output",
+		Label: "FILE", Help: "Write to FILE",
+	}
+	jsonFlag := &clim.Flag{
+		Value: clim.Bool(&json, false), Long: "json", Help: "Output JSON",
+	}
+	proxyFlag := &clim.Flag{
+		Value: clim.String(&proxy, ""), Long: "proxy", Help: "Proxy URL",
+	}
+	timeoutFlag := &clim.Flag{
+		Value: clim.Int(&timeout, 30), Long: "timeout-seconds", Label: "N",
+		Help: "Connection timeout",
+	}
+	err = cli.AddFlags(
+		outputFlag,
+		&clim.Flag{
+			Value: clim.Bool(&verbose, false), Short: "v", Long: "verbose",
+			Help: "Be verbose",
+		},
+		timeoutFlag,
+		jsonFlag,
+		proxyFlag)
+	rosina.AssertNoError(t, err)
+
+	// The order of the flags in a group is the order of AddFlags.
+	err = cli.AddFlagGroup("Output", jsonFlag, outputFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlagGroup("Networking", proxyFlag, timeoutFlag)
+	rosina.AssertNoError(t, err)
+
+	want := `bang -- bang head
+
+Usage: bang [options]
+
+Options:
+
+ -v, --verbose          Be verbose (default: false)
+
+ -h, --help             Print this help and exit
+
+Output:
+
+ -o, --output FILE      Write to FILE
+ --json                 Output JSON (default: false)
+
+Networking:
+
+ --timeout-seconds N    Connection timeout (default: 30)
+ --proxy PROXY          Proxy URL
+`
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
+
+func TestHelpWrapping(t *testing.T) {
+	type testCase struct {
+		name    string
+		width   int
+		columns string // value of env COLUMNS; empty means unset
+		want    string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		t.Setenv("COLUMNS", tc.columns)
+		var force bool
+		var rev []string
+		var positionals []string
+		cli, err := clim.NewTop[any]("hg", "Mercurial Distributed SCM", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(
+			&clim.Flag{
+				Value: clim.Bool(&force, false), Short: "f", Long: "force",
+				Help: "run even if remote repository is unrelated",
+			},
+			&clim.Flag{
+				Value: clim.StringSlice(&rev, nil), Short: "r", Long: "rev",
+				Label: "REV[,REV,..]",
+				Help:  "a remote changeset intended to be added",
+			})
+		rosina.AssertNoError(t, err)
+		err = cli.AddPosArgs(&positionals,
+			clim.Pair{"SOURCE", "the source repository, either a path or an URL"})
+		rosina.AssertNoError(t, err)
+		cli.SetHelpWidth(tc.width)
+
+		_, err = cli.Parse([]string{"-h"})
+
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		rosina.AssertTextEqual(t, err.Error(), tc.want, "help message")
+	}
+
+	testCases := []testCase{
+		{
+			name:  "explicit width",
+			width: 50,
+			want: `hg -- Mercurial Distributed SCM
+
+Usage: hg [options] SOURCE
+
+Options:
+
+ -f, --force               run even if remote
+                           repository is unrelated
+                           (default: false)
+ -r, --rev REV[,REV,..]    a remote changeset
+                           intended to be added
+
+ -h, --help                Print this help and exit
+
+Positional arguments:
+
+ SOURCE      the source repository, either a path
+             or an URL
+`,
+		},
+		{
+			name:    "width from COLUMNS",
+			columns: "60",
+			want: `hg -- Mercurial Distributed SCM
+
+Usage: hg [options] SOURCE
+
+Options:
+
+ -f, --force               run even if remote repository is
+                           unrelated (default: false)
+ -r, --rev REV[,REV,..]    a remote changeset intended to be
+                           added
+
+ -h, --help                Print this help and exit
+
+Positional arguments:
+
+ SOURCE      the source repository, either a path or an URL
+`,
+		},
+		{
+			name:    "no wrapping",
+			width:   -1,
+			columns: "20",
+			want: `hg -- Mercurial Distributed SCM
+
+Usage: hg [options] SOURCE
+
+Options:
+
+ -f, --force               run even if remote repository is unrelated (default: false)
+ -r, --rev REV[,REV,..]    a remote changeset intended to be added
+
+ -h, --help                Print this help and exit
+
+Positional arguments:
+
+ SOURCE      the source repository, either a path or an URL
+`,
+		},
+		{
+			name:  "minimum width of second column",
+			width: 10,
+			want: `hg -- Mercurial Distributed SCM
+
+Usage: hg [options] SOURCE
+
+Options:
+
+ -f, --force               run even if remote
+                           repository is
+                           unrelated (default:
+                           false)
+ -r, --rev REV[,REV,..]    a remote changeset
+                           intended to be added
+
+ -h, --help                Print this help and exit
+
+Positional arguments:
+
+ SOURCE      the source
+             repository, either a
+             path or an URL
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHelpMaxColumn(t *testing.T) {
+	var force bool
+	var rev []string
+	var positionals []string
+	cli, err := clim.NewTop[any]("hg", "Mercurial Distributed SCM", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(
+		&clim.Flag{
+			Value: clim.Bool(&force, false), Short: "f", Long: "force",
+			Help: "run even if remote repository is unrelated",
+		},
+		&clim.Flag{
+			Value: clim.StringSlice(&rev, nil), Short: "r", Long: "rev",
+			Label: "REV[,REV,..]",
+			Help:  "a remote changeset intended to be added",
+		})
+	rosina.AssertNoError(t, err)
+	err = cli.AddPosArgs(&positionals,
+		clim.Pair{"SOURCE", "the source repository, either a path or an URL"})
+	rosina.AssertNoError(t, err)
+	cli.SetHelpWidth(60)
+	cli.SetHelpMaxColumn(20)
+
+	want := `hg -- Mercurial Distributed SCM
+
+Usage: hg [options] SOURCE
+
+Options:
+
+ -f, --force     run even if remote repository is unrelated
+                 (default: false)
+ -r, --rev REV[,REV,..]
+                 a remote changeset intended to be added
+
+ -h, --help      Print this help and exit
+
+Positional arguments:
+
+ SOURCE      the source repository, either a path or an URL
+`
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
+
+func TestShortHelp(t *testing.T) {
+	type testCase struct {
+		name      string
+		shortHelp bool
+		args      []string
+		want      string
+	}
+
+	full := `bang -- bang head
+
+ The description.
+
+Usage: bang [options]
+
+Examples:
+
+ bang -c 3
+
+Options:
+
+ -c, --count COUNT    How many times (default: 1)
+                      Each time is slower than the previous one.
+
+ -h, --help           Print this help and exit
+
+ The footer.
+`
+	brief := `bang -- bang head
+
+Usage: bang [options]
+
+Options:
+
+ -c, --count COUNT    How many times (default: 1)
+
+ -h, --help           Print this help and exit
+
+Use "bang --help" for the full help.
+`
+
+	test := func(t *testing.T, tc testCase) {
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetShortHelp(tc.shortHelp)
+		cli.SetDescription("The description.")
+		cli.SetExamples("bang -c 3")
+		cli.SetFooter("The footer.")
+		err = cli.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 1), Short: "c", Long: "count",
+			Help:     "How many times",
+			LongHelp: "Each time is slower than the previous one.",
+		})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		rosina.AssertTextEqual(t, err.Error(), tc.want, "help message")
+	}
+
+	testCases := []testCase{
+		{
+			name: "disabled, short",
+			args: []string{"-h"},
+			want: full,
+		},
+		{
+			name: "disabled, long",
+			args: []string{"--help"},
+			want: full,
+		},
+		{
+			name:      "enabled, short",
+			shortHelp: true,
+			args:      []string{"-h"},
+			want:      brief,
+		},
+		{
+			name:      "enabled, long",
+			shortHelp: true,
+			args:      []string{"--help"},
+			want:      full,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestSynopsis(t *testing.T) {
+	type testCase struct {
+		name  string
+		setup func(t *testing.T, cli *clim.CLI[any])
+		want  []string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		var spy spyRenderer
+		cli.SetHelpRenderer(&spy)
+		tc.setup(t, cli)
+
+		_, err = cli.Parse([]string{"-h"})
+
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		rosina.AssertDeepEqual(t, spy.help.Synopsis, tc.want, "synopsis")
+	}
+
+	var count, level int
+	var force, dry, wet bool
+	var names []string
+
+	testCases := []testCase{
+		{
+			name:  "nothing",
+			setup: func(t *testing.T, cli *clim.CLI[any]) {},
+			want:  []string{"bang [options]"},
+		},
+		{
+			name: "required flags, hidden ones omitted",
+			setup: func(t *testing.T, cli *clim.CLI[any]) {
+				err := cli.AddFlags(
+					&clim.Flag{
+						Value: clim.Int(&count, 0), Long: "count", Label: "N",
+						Required: true,
+					},
+					&clim.Flag{
+						Value: clim.Bool(&force, false), Long: "force",
+						Required: true,
+					},
+					&clim.Flag{
+						Value: clim.Int(&level, 0), Long: "level",
+						Required: true, Hidden: true,
+					})
+				rosina.AssertNoError(t, err)
+			},
+			want: []string{"bang --count N --force [options]"},
+		},
+		{
+			name: "alternatives",
+			setup: func(t *testing.T, cli *clim.CLI[any]) {
+				err := cli.AddFlags(
+					&clim.Flag{Value: clim.Int(&count, 0), Long: "count"},
+					&clim.Flag{Value: clim.Int(&level, 0), Long: "level"},
+					&clim.Flag{Value: clim.Bool(&dry, false), Long: "dry"},
+					&clim.Flag{Value: clim.Bool(&wet, false), Long: "wet"},
+				)
+				rosina.AssertNoError(t, err)
+				err = cli.AddConstraints(
+					clim.ExactlyOne("count", "level"),
+					clim.AtMostOne("dry", "wet"),
+					clim.AllOrNone("count", "dry"))
+				rosina.AssertNoError(t, err)
+			},
+			want: []string{
+				"bang (--count COUNT | --level LEVEL) [--dry | --wet] [options]",
+			},
+		},
+		{
+			name: "optional and repeated positionals",
+			setup: func(t *testing.T, cli *clim.CLI[any]) {
+				err := cli.AddPosArgs(&names,
+					clim.Pair{"SRC...", "The sources"},
+					clim.Pair{"[DST]", "The destination"})
+				rosina.AssertNoError(t, err)
+			},
+			want: []string{"bang [options] SRC... [DST]"},
+		},
+		{
+			name: "subcommands after the flags",
+			setup: func(t *testing.T, cli *clim.CLI[any]) {
+				err := cli.AddFlags(&clim.Flag{
+					Value: clim.Int(&count, 0), Long: "count", Required: true,
+				})
+				rosina.AssertNoError(t, err)
+				_, err = clim.NewSub[any](cli, "sub", "I am sub",
+					func(uctx any) error { return nil })
+				rosina.AssertNoError(t, err)
+				// The synopsis shows what Parse accepts.
+				_, err = cli.Parse([]string{"--count", "3", "sub"})
+				rosina.AssertNoError(t, err)
+			},
+			want: []string{"bang --count COUNT [options] <command>"},
+		},
+		{
+			name: "set by the user",
+			setup: func(t *testing.T, cli *clim.CLI[any]) {
+				cli.SetSynopsis("[options] SRC DST", "[options] SRC... DIR")
+			},
+			want: []string{"bang [options] SRC DST", "bang [options] SRC... DIR"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHelpOfMultipleSynopsis(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetSynopsis("[options] SRC DST", "[options] SRC... DIR")
+
+	want := `bang -- bang head
+
+Usage: bang [options] SRC DST
+       bang [options] SRC... DIR
+
+Options:
+
+ -h, --help    Print this help and exit
+`
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
@@ -0,0 +1,111 @@
+// This file contains the "help" subcommand and the help topics.
+
+package clim
+
+import "strings"
+
+// helpTopic is a free-form help page. See [CLI.AddHelpTopic].
+type helpTopic struct {
+	name    string
+	oneline string
+	text    string
+}
+
+// SetHelpCommand enables (or disables) the "help" subcommand, so that the user
+// can type "hg help clone" as an alternative to "hg clone --help", or
+// "hg help revsets" to read a help topic (see [CLI.AddHelpTopic]).
+// The "help" subcommand is available on each command that has subcommands or
+// help topics, and it resolves its arguments starting from that command.
+// A subcommand explicitly named "help" takes precedence.
+func (cli *CLI[T]) SetHelpCommand(enable bool) {
+	cli.rootOnly("SetHelpCommand")
+	cli.helpCmd = enable
+}
+
+// AddHelpTopic adds a free-form help topic, with 'name' (for example
+// "revsets"), one-line description 'oneline' and content 'text'. The topic is
+// listed in the help of cli and is shown by "help <name>"; see
+// [CLI.SetHelpCommand].
+func (cli *CLI[T]) AddHelpTopic(name string, oneline string, text string) error {
+	if name == "" {
+		return cli.definitionError("%s: help topic name cannot be empty", cli.rootToHere)
+	}
+	for _, topic := range cli.topics {
+		if topic.name == name {
+			return cli.definitionError("%s: help topic %q already defined",
+				cli.rootToHere, name)
+		}
+	}
+	for _, sub := range cli.subCLIs {
+		if sub.name == name {
+			return cli.definitionError("%s: help topic %q: already defined as subcommand",
+				cli.rootToHere, name)
+		}
+	}
+	cli.topics = append(cli.topics, helpTopic{
+		name:    name,
+		oneline: oneline,
+		text:    strings.TrimSpace(text),
+	})
+	return nil
+}
+
+// hasHelpCommand returns true if the "help" subcommand is available on cli.
+func (cli *CLI[T]) hasHelpCommand() bool {
+	if !cli.root().helpCmd || cli.posargs != nil {
+		return false
+	}
+	if len(cli.subCLIs) == 0 && len(cli.topics) == 0 {
+		return false
+	}
+	for _, sub := range cli.subCLIs {
+		if sub.name == "help" {
+			return false
+		}
+	}
+	return true
+}
+
+// helpFor implements the "help" subcommand: it resolves 'args' as a path of
+// subcommands starting from cli, optionally ending with a help topic, and
+// returns the corresponding help as an error that unwraps to [ErrHelp].
+// Parameter 'at' is the index of args[0] in the args of [CLI.Parse].
+func (cli *CLI[T]) helpFor(args []string, at int) error {
+	node := cli
+	for i, name := range args {
+		if sub := node.findSub(name); sub != nil {
+			node = sub
+			continue
+		}
+		if i == len(args)-1 {
+			for _, topic := range node.topics {
+				if topic.name == name {
+					return newHelpError("%s -- %s\n\n%s\n",
+						node.rootToHere+" help "+topic.name, topic.oneline, topic.text)
+				}
+			}
+		}
+		return cli.newTokenError(KindUnknownCommand, name, at+i,
+			"help: unknown command or topic %q", strings.Join(args[:i+1], " "))
+	}
+	return node.usage()
+}
+
+// findSub returns the subcommand of cli with 'name', or nil if not found.
+func (cli *CLI[T]) findSub(name string) *CLI[T] {
+	for _, sub := range cli.subCLIs {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// helpTopics returns the help topics of cli, for [Help].
+func (cli *CLI[T]) helpTopics() []HelpCommand {
+	var topics []HelpCommand
+	for _, topic := range cli.topics {
+		topics = append(topics, HelpCommand{Name: topic.name, Oneline: topic.oneline})
+	}
+	return topics
+}
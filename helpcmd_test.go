@@ -0,0 +1,230 @@
+package clim_test
+
+import (
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestHelpCommand(t *testing.T) {
+	type testCase struct {
+		name string
+		args []string
+		want string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetHelpCommand(true)
+		err = cli.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 0), Long: "count", Required: true,
+		})
+		rosina.AssertNoError(t, err)
+		remote, err := clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+		rosina.AssertNoError(t, err)
+		_, err = clim.NewSub[any](remote, "add", "Add a remote", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddHelpTopic("revsets", "Specifying revisions", `
+A revset is an expression that selects revisions.
+`)
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		rosina.AssertTextEqual(t, err.Error(), tc.want, "help message")
+	}
+
+	testCases := []testCase{
+		{
+			name: "no arguments",
+			args: []string{"help"},
+			want: `bang -- bang head
+
+Usage: bang --count COUNT [options] <command>
+
+Commands:
+
+ remote      Manage remotes
+
+Help topics:
+
+ revsets     Specifying revisions
+
+Use "bang help <command|topic>" for more information.
+
+Options:
+
+ --count COUNT     (required)
+
+ -h, --help       Print this help and exit
+`,
+		},
+		{
+			name: "nested command",
+			args: []string{"help", "remote", "add"},
+			want: `bang remote add -- Add a remote
+
+Usage: bang remote add [options]
+
+Options:
+
+ -h, --help    Print this help and exit
+`,
+		},
+		{
+			name: "from a subcommand",
+			args: []string{"--count=1", "remote", "help"},
+			want: `bang remote -- Manage remotes
+
+Usage: bang remote [options] <command>
+
+Commands:
+
+ add     Add a remote
+
+Use "bang remote help <command>" for more information.
+
+Options:
+
+ -h, --help    Print this help and exit
+`,
+		},
+		{
+			name: "topic",
+			args: []string{"help", "revsets"},
+			want: `bang help revsets -- Specifying revisions
+
+A revset is an expression that selects revisions.
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHelpCommandFailure(t *testing.T) {
+	type testCase struct {
+		name    string
+		args    []string
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetHelpCommand(true)
+		remote, err := clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+		rosina.AssertNoError(t, err)
+		_, err = clim.NewSub[any](remote, "add", "Add a remote", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddHelpTopic("revsets", "Specifying revisions", "text")
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:    "unknown command",
+			args:    []string{"help", "banana"},
+			wantErr: `help: unknown command or topic "banana"`,
+		},
+		{
+			name:    "topic is not a command",
+			args:    []string{"help", "revsets", "add"},
+			wantErr: `help: unknown command or topic "revsets"`,
+		},
+		{
+			name:    "topic of another command",
+			args:    []string{"help", "remote", "revsets"},
+			wantErr: `help: unknown command or topic "remote revsets"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHelpCommandDisabled(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"help", "remote"})
+
+	rosina.AssertErrorContains(t, err, `unrecognized command "help"`)
+}
+
+func TestHelpCommandOverridden(t *testing.T) {
+	var called bool
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetHelpCommand(true)
+	_, err = clim.NewSub[any](cli, "help", "My own help",
+		func(uctx any) error {
+			called = true
+			return nil
+		})
+	rosina.AssertNoError(t, err)
+
+	action, err := cli.Parse([]string{"help"})
+	rosina.AssertNoError(t, err)
+	err = action(nil)
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, called, true, "called")
+}
+
+func TestAddHelpTopicFailure(t *testing.T) {
+	type testCase struct {
+		name    string
+		topic   string
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		_, err = clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddHelpTopic("revsets", "Specifying revisions", "text")
+		rosina.AssertNoError(t, err)
+
+		err = cli.AddHelpTopic(tc.topic, "oneline", "text")
+
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:    "empty name",
+			topic:   "",
+			wantErr: "bang: help topic name cannot be empty",
+		},
+		{
+			name:    "duplicate topic",
+			topic:   "revsets",
+			wantErr: `bang: help topic "revsets" already defined`,
+		},
+		{
+			name:    "clash with subcommand",
+			topic:   "remote",
+			wantErr: `bang: help topic "remote": already defined as subcommand`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
@@ -0,0 +1,107 @@
+// This file contains the hooks and the middlewares run around the action.
+
+package clim
+
+import (
+	"errors"
+	"slices"
+)
+
+// SetBefore sets the pre-run hook of cli. When the action returned by
+// [CLI.Parse] is called, it first calls the pre-run hooks of the commands from
+// the top-level CLI to the selected one, in this order, thus after Parse has
+// set the values of all the flags. A hook returning an error aborts: the
+// following hooks and the action are not called.
+// Use it, for example, to configure logging or to open a database in a parent
+// command, for all its subcommands.
+func (cli *CLI[T]) SetBefore(hook func(uctx T) error) {
+	cli.before = hook
+}
+
+// SetAfter sets the post-run hook of cli. Once the action returned by
+// [CLI.Parse] has run, it calls the post-run hooks in reverse order, from the
+// selected command to the top-level CLI. As a deferred call, the post-run
+// hook of a command is called even if the action (or a following pre-run
+// hook) failed, provided that the pre-run hook of the command, if any,
+// succeeded. All the errors are returned, joined with [errors.Join].
+// Use it, for example, to close what [CLI.SetBefore] opened.
+func (cli *CLI[T]) SetAfter(hook func(uctx T) error) {
+	cli.after = hook
+}
+
+// runHooks calls the pre-run hooks from the root to cli, the action of cli
+// and then the post-run hooks in reverse. See [CLI.SetBefore].
+func (cli *CLI[T]) runHooks(uctx T) error {
+	var path []*CLI[T]
+	for node := cli; node != nil; node = node.parent {
+		path = append(path, node)
+	}
+	slices.Reverse(path)
+
+	var errs []error
+	entered := 0 // Commands whose pre-run hook succeeded (or is missing).
+	for _, node := range path {
+		if node.before != nil {
+			if err := node.before(uctx); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+		entered++
+	}
+	if entered == len(path) {
+		if err := cli.action(uctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := entered - 1; i >= 0; i-- {
+		if path[i].after != nil {
+			if err := path[i].after(uctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errors.Join(errs...)
+	}
+}
+
+// Middleware wraps an action: it returns a function that does something
+// before and/or after calling 'next'. See [CLI.Use].
+type Middleware[T any] func(next func(uctx T) error) func(uctx T) error
+
+// Use adds middlewares to cli. The action returned by [CLI.Parse] is wrapped
+// by the middlewares of the commands from the top-level CLI to the selected
+// one, thus a middleware is inherited by all the subcommands. The first
+// middleware is the outermost one: it is called first and returns last.
+// A middleware wraps also the hooks (see [CLI.SetBefore]).
+// Use it, for example, for timing, tracing, panic recovery or audit logging:
+//
+//	cli.Use(func(next func(uctx *App) error) func(uctx *App) error {
+//	    return func(uctx *App) error {
+//	        start := time.Now()
+//	        err := next(uctx)
+//	        log.Println("elapsed", time.Since(start))
+//	        return err
+//	    }
+//	})
+func (cli *CLI[T]) Use(middlewares ...Middleware[T]) {
+	cli.middlewares = append(cli.middlewares, middlewares...)
+}
+
+// wrap returns 'action' wrapped by the middlewares of the commands from the
+// root to cli. See [CLI.Use].
+func (cli *CLI[T]) wrap(action func(uctx T) error) func(uctx T) error {
+	for node := cli; node != nil; node = node.parent {
+		for i := len(node.middlewares) - 1; i >= 0; i-- {
+			action = node.middlewares[i](action)
+		}
+	}
+	return action
+}
@@ -0,0 +1,186 @@
+package clim_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestHooks(t *testing.T) {
+	type testCase struct {
+		name      string
+		args      []string
+		fail      string // Which hook or action fails.
+		wantTrace []string
+		wantErr   string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var trace []string
+		var level int
+		hook := func(name string) func(uctx *[]string) error {
+			return func(uctx *[]string) error {
+				*uctx = append(*uctx, name)
+				if name == tc.fail {
+					return fmt.Errorf("%s failed", name)
+				}
+				return nil
+			}
+		}
+		cli, err := clim.NewTop[*[]string]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(&clim.Flag{Value: clim.Int(&level, 0), Long: "level"})
+		rosina.AssertNoError(t, err)
+		cli.SetBefore(func(uctx *[]string) error {
+			*uctx = append(*uctx, fmt.Sprintf("level=%d", level))
+			return hook("top before")(uctx)
+		})
+		cli.SetAfter(hook("top after"))
+		mid, err := clim.NewSub[*[]string](cli, "mid", "I am mid", nil)
+		rosina.AssertNoError(t, err)
+		mid.SetBefore(hook("mid before"))
+		mid.SetAfter(hook("mid after"))
+		// No hooks for leaf.
+		_, err = clim.NewSub(mid, "leaf", "I am leaf", hook("leaf action"))
+		rosina.AssertNoError(t, err)
+
+		action, err := cli.Parse(tc.args)
+		rosina.AssertNoError(t, err)
+		err = action(&trace)
+
+		rosina.AssertDeepEqual(t, trace, tc.wantTrace, "trace")
+		if tc.wantErr == "" {
+			rosina.AssertNoError(t, err)
+		} else {
+			rosina.AssertTextEqual(t, err.Error(), tc.wantErr, "error")
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			args: []string{"--level=3", "mid", "leaf"},
+			wantTrace: []string{
+				"level=3", "top before", "mid before", "leaf action",
+				"mid after", "top after",
+			},
+		},
+		{
+			name: "action fails, after hooks still called",
+			args: []string{"mid", "leaf"},
+			fail: "leaf action",
+			wantTrace: []string{
+				"level=0", "top before", "mid before", "leaf action",
+				"mid after", "top after",
+			},
+			wantErr: "leaf action failed",
+		},
+		{
+			name: "before fails, only the previous after hooks called",
+			args: []string{"mid", "leaf"},
+			fail: "mid before",
+			wantTrace: []string{
+				"level=0", "top before", "mid before", "top after",
+			},
+			wantErr: "mid before failed",
+		},
+		{
+			name: "after fails",
+			args: []string{"mid", "leaf"},
+			fail: "mid after",
+			wantTrace: []string{
+				"level=0", "top before", "mid before", "leaf action",
+				"mid after", "top after",
+			},
+			wantErr: "mid after failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHooksJoinErrors(t *testing.T) {
+	errAction := errors.New("action failed")
+	errAfter := errors.New("after failed")
+	cli, err := clim.NewTop[any]("bang", "bang head",
+		func(uctx any) error { return errAction })
+	rosina.AssertNoError(t, err)
+	cli.SetAfter(func(uctx any) error { return errAfter })
+
+	action, err := cli.Parse(nil)
+	rosina.AssertNoError(t, err)
+	err = action(nil)
+
+	rosina.AssertErrorIs(t, err, errAction)
+	rosina.AssertErrorIs(t, err, errAfter)
+	rosina.AssertTextEqual(t, err.Error(),
+		strings.Join([]string{"action failed", "after failed"}, "\n"), "error")
+}
+
+func TestMiddleware(t *testing.T) {
+	var trace []string
+	mw := func(name string) clim.Middleware[*[]string] {
+		return func(next func(uctx *[]string) error) func(uctx *[]string) error {
+			return func(uctx *[]string) error {
+				*uctx = append(*uctx, name+" in")
+				err := next(uctx)
+				*uctx = append(*uctx, name+" out")
+				return err
+			}
+		}
+	}
+	cli, err := clim.NewTop[*[]string]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.Use(mw("top 1"), mw("top 2"))
+	cli.SetBefore(func(uctx *[]string) error {
+		*uctx = append(*uctx, "top before")
+		return nil
+	})
+	sub, err := clim.NewSub(cli, "sub", "I am sub",
+		func(uctx *[]string) error {
+			*uctx = append(*uctx, "sub action")
+			return nil
+		})
+	rosina.AssertNoError(t, err)
+	sub.Use(mw("sub"))
+
+	action, err := cli.Parse([]string{"sub"})
+	rosina.AssertNoError(t, err)
+	err = action(&trace)
+
+	rosina.AssertNoError(t, err)
+	want := []string{
+		"top 1 in", "top 2 in", "sub in", "top before", "sub action",
+		"sub out", "top 2 out", "top 1 out",
+	}
+	rosina.AssertDeepEqual(t, trace, want, "trace")
+}
+
+func TestMiddlewareRecover(t *testing.T) {
+	recoverer := func(next func(uctx any) error) func(uctx any) error {
+		return func(uctx any) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(uctx)
+		}
+	}
+	cli, err := clim.NewTop[any]("bang", "bang head",
+		func(uctx any) error { panic("boom") })
+	rosina.AssertNoError(t, err)
+	cli.Use(recoverer)
+
+	action, err := cli.Parse(nil)
+	rosina.AssertNoError(t, err)
+	err = action(nil)
+
+	rosina.AssertTextEqual(t, err.Error(), "panic: boom", "error")
+}
@@ -0,0 +1,243 @@
+// This file contains the generation of man pages from the command tree.
+
+package clim
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManOptions are the options for [CLI.WriteManPage] and [CLI.WriteManPages].
+// They fill the title line (.TH) of the man page.
+type ManOptions struct {
+	Section string // Manual section. Optional, default "1".
+	Date    string // Date of the last change, for example "2024-08-15". Optional.
+	Source  string // Source of the command, for example "hg 6.8". Optional.
+	Manual  string // Title of the manual, for example "User Commands". Optional.
+}
+
+// WriteManPages writes to directory 'dir' (which must exist) the roff man
+// pages of cli and of all its subcommands, recursively, skipping the hidden
+// ones. Each file is named after the command path, for example "hg-clone.1".
+// The page of cli acts also as index: its SEE ALSO section references all the
+// other pages.
+func (cli *CLI[T]) WriteManPages(dir string, opts ManOptions) error {
+	for _, node := range cli.visibleTree() {
+		name := manName(node.rootToHere) + "." + manSection(opts)
+		fi, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("clim: man page: %w", err)
+		}
+		if err := node.writeManPage(fi, opts, cli); err != nil {
+			fi.Close()
+			return err
+		}
+		if err := fi.Close(); err != nil {
+			return fmt.Errorf("clim: man page: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteManPage writes to 'w' the roff man page of cli alone. As with
+// [CLI.WriteManPages], the page of the top-level CLI acts as index.
+func (cli *CLI[T]) WriteManPage(w io.Writer, opts ManOptions) error {
+	return cli.writeManPage(w, opts, cli.root())
+}
+
+// writeManPage writes the man page of cli. Parameter 'index' is the node whose
+// page references all the pages of its tree.
+func (cli *CLI[T]) writeManPage(w io.Writer, opts ManOptions, index *CLI[T]) error {
+	help := cli.help()
+	section := manSection(opts)
+	var bld strings.Builder
+
+	fmt.Fprintf(&bld, ".TH %s %s %s %s %s\n",
+		manQuote(strings.ToUpper(manName(help.Path))), manQuote(section),
+		manQuote(opts.Date), manQuote(opts.Source), manQuote(opts.Manual))
+
+	fmt.Fprintf(&bld, ".SH NAME\n%s \\- %s\n",
+		manEscape(manName(help.Path)), manEscape(help.Oneline))
+
+	fmt.Fprintf(&bld, ".SH SYNOPSIS\n")
+	for i, line := range help.Synopsis {
+		if i > 0 {
+			fmt.Fprintf(&bld, ".br\n")
+		}
+		args := strings.TrimPrefix(strings.TrimPrefix(line, help.Path), " ")
+		fmt.Fprintf(&bld, "\\fB%s\\fR %s\n", manEscape(help.Path), manEscape(args))
+	}
+
+	if help.Description != "" {
+		fmt.Fprintf(&bld, ".SH DESCRIPTION\n")
+		manParagraphs(&bld, help.Description)
+	}
+
+	if len(help.Commands) > 0 {
+		fmt.Fprintf(&bld, ".SH COMMANDS\n")
+		if len(help.Groups) > 0 {
+			for _, group := range help.Groups {
+				fmt.Fprintf(&bld, ".SS %s\n", manEscape(group.Name))
+				manCommands(&bld, group.Commands)
+			}
+		} else {
+			manCommands(&bld, help.Commands)
+		}
+	}
+
+	fmt.Fprintf(&bld, ".SH OPTIONS\n")
+	for _, flag := range help.Flags {
+		manFlag(&bld, flag)
+	}
+	fmt.Fprintf(&bld, ".TP\n\\fB\\-h\\fR, \\fB\\-\\-help\\fR\nPrint this help and exit\n")
+	for _, group := range help.FlagGroups {
+		fmt.Fprintf(&bld, ".SS %s\n", manEscape(group.Name))
+		for _, flag := range group.Flags {
+			manFlag(&bld, flag)
+		}
+	}
+
+	if len(help.Constraints) > 0 {
+		fmt.Fprintf(&bld, ".SH CONSTRAINTS\n")
+		for i, c := range help.Constraints {
+			if i > 0 {
+				fmt.Fprintf(&bld, ".br\n")
+			}
+			fmt.Fprintf(&bld, "%s\n", manEscape(c))
+		}
+	}
+
+	if len(help.Positionals) > 0 {
+		fmt.Fprintf(&bld, ".SH ARGUMENTS\n")
+		for _, pa := range help.Positionals {
+			fmt.Fprintf(&bld, ".TP\n\\fI%s\\fR\n%s\n",
+				manEscape(pa.Name), manEscape(pa.Help))
+		}
+	}
+
+	if help.Examples != "" {
+		fmt.Fprintf(&bld, ".SH EXAMPLES\n.RS 4\n.nf\n")
+		for _, line := range strings.Split(help.Examples, "\n") {
+			fmt.Fprintf(&bld, "%s\n", manEscape(line))
+		}
+		fmt.Fprintf(&bld, ".fi\n.RE\n")
+	}
+
+	if help.Footer != "" {
+		fmt.Fprintf(&bld, ".SH NOTES\n")
+		manParagraphs(&bld, help.Footer)
+	}
+
+	if seeAlso := cli.seeAlso(index); len(seeAlso) > 0 {
+		fmt.Fprintf(&bld, ".SH SEE ALSO\n")
+		for i, node := range seeAlso {
+			sep := ","
+			if i == len(seeAlso)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&bld, "\\fB%s\\fR(%s)%s\n",
+				manEscape(manName(node.rootToHere)), section, sep)
+		}
+	}
+
+	if _, err := io.WriteString(w, bld.String()); err != nil {
+		return fmt.Errorf("clim: man page: %w", err)
+	}
+	return nil
+}
+
+// seeAlso returns the nodes referenced by the man page of cli: for the index,
+// all the other nodes of the tree; for the other nodes, the parent and the
+// visible subcommands.
+func (cli *CLI[T]) seeAlso(index *CLI[T]) []*CLI[T] {
+	if cli == index {
+		return cli.visibleTree()[1:]
+	}
+	var nodes []*CLI[T]
+	if cli.parent != nil {
+		nodes = append(nodes, cli.parent)
+	}
+	for _, sub := range cli.subCLIs {
+		if !sub.hidden {
+			nodes = append(nodes, sub)
+		}
+	}
+	return nodes
+}
+
+// visibleTree returns cli and all its subcommands, recursively, depth-first,
+// skipping the hidden ones.
+func (cli *CLI[T]) visibleTree() []*CLI[T] {
+	nodes := []*CLI[T]{cli}
+	for _, sub := range cli.subCLIs {
+		if !sub.hidden {
+			nodes = append(nodes, sub.visibleTree()...)
+		}
+	}
+	return nodes
+}
+
+func manCommands(bld *strings.Builder, commands []HelpCommand) {
+	for _, cmd := range commands {
+		fmt.Fprintf(bld, ".TP\n\\fB%s\\fR\n%s\n",
+			manEscape(cmd.Name), manEscape(cmd.Oneline))
+	}
+}
+
+func manFlag(bld *strings.Builder, flag HelpFlag) {
+	fmt.Fprintf(bld, ".TP\n")
+	if flag.Short != "" {
+		fmt.Fprintf(bld, "\\fB\\-%s\\fR, ", manEscape(flag.Short))
+	}
+	fmt.Fprintf(bld, "\\fB\\-\\-%s\\fR", manEscape(flag.Long))
+	if flag.Label != "" {
+		fmt.Fprintf(bld, " \\fI%s\\fR", manEscape(flag.Label))
+	}
+	fmt.Fprintf(bld, "\n%s\n", manEscape(strings.TrimSpace(flag.Notes())))
+	if flag.LongHelp != "" {
+		fmt.Fprintf(bld, ".br\n")
+		manParagraphs(bld, flag.LongHelp)
+	}
+}
+
+// manParagraphs writes 'text', where empty lines separate paragraphs.
+func manParagraphs(bld *strings.Builder, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			fmt.Fprintf(bld, ".PP\n")
+			continue
+		}
+		fmt.Fprintf(bld, "%s\n", manEscape(strings.TrimSpace(line)))
+	}
+}
+
+// manName returns the name of the man page of the command with 'path'.
+func manName(path string) string {
+	return strings.ReplaceAll(path, " ", "-")
+}
+
+func manSection(opts ManOptions) string {
+	if opts.Section == "" {
+		return "1"
+	}
+	return opts.Section
+}
+
+// manEscape escapes 'text' for roff: backslashes, hyphens and control
+// characters at the beginning of the line.
+func manEscape(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// manQuote returns 'text' escaped and quoted, as an argument of a roff macro.
+func manQuote(text string) string {
+	return `"` + strings.ReplaceAll(manEscape(text), `"`, `\(dq`) + `"`
+}
@@ -0,0 +1,199 @@
+package clim_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+var manOpts = clim.ManOptions{
+	Date: "2024-08-15", Source: "hg 6.8", Manual: "User Commands",
+}
+
+// newHgCLI returns the command tree shared by the golden tests of the
+// reference documentation: man pages, Markdown and HTML.
+func newHgCLI(t *testing.T) *clim.CLI[any] {
+	t.Helper()
+	var noUpdate, verbose bool
+	var rev string
+	var positionals []string
+
+	cli, err := clim.NewTop[any]("hg", "Mercurial Distributed SCM", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetDescription("A distributed SCM.\n\nFast and simple.")
+	err = cli.AddFlags(&clim.Flag{
+		Value: clim.Bool(&verbose, false), Short: "v", Long: "verbose",
+		Help: "enable additional output",
+	})
+	rosina.AssertNoError(t, err)
+
+	clone, err := clim.NewSub[any](cli, "clone",
+		"make a copy of an existing repository", nil)
+	rosina.AssertNoError(t, err)
+	clone.SetExamples("hg clone https://example.org/repo\nhg clone -U .")
+	clone.SetFooter("Returns 0 on success.")
+	err = clone.AddFlags(
+		&clim.Flag{
+			Value: clim.Bool(&noUpdate, false), Short: "U", Long: "noupdate",
+			Help: "the clone will include an empty working directory",
+		},
+		&clim.Flag{
+			Value: clim.String(&rev, ""), Short: "u", Long: "updaterev",
+			Label: "REV", Help: "revision to check out", Required: true,
+		})
+	rosina.AssertNoError(t, err)
+	err = clone.AddConstraints(clim.AtMostOne("noupdate", "updaterev"))
+	rosina.AssertNoError(t, err)
+	err = clone.AddPosArgs(&positionals,
+		clim.Pair{"SOURCE", "the source repository"},
+		clim.Pair{"DEST", "the destination directory"})
+	rosina.AssertNoError(t, err)
+
+	secret, err := clim.NewSub[any](cli, "secret", "hidden command", nil)
+	rosina.AssertNoError(t, err)
+	secret.SetHidden(true)
+
+	return cli
+}
+
+func TestWriteManPages(t *testing.T) {
+	wantHg := `.TH "HG" "1" "2024\-08\-15" "hg 6.8" "User Commands"
+.SH NAME
+hg \- Mercurial Distributed SCM
+.SH SYNOPSIS
+\fBhg\fR [options] <command>
+.SH DESCRIPTION
+A distributed SCM.
+.PP
+Fast and simple.
+.SH COMMANDS
+.TP
+\fBclone\fR
+make a copy of an existing repository
+.SH OPTIONS
+.TP
+\fB\-v\fR, \fB\-\-verbose\fR
+enable additional output (default: false)
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Print this help and exit
+.SH SEE ALSO
+\fBhg\-clone\fR(1)
+`
+	wantClone := `.TH "HG\-CLONE" "1" "2024\-08\-15" "hg 6.8" "User Commands"
+.SH NAME
+hg\-clone \- make a copy of an existing repository
+.SH SYNOPSIS
+\fBhg clone\fR [\-\-noupdate | \-\-updaterev REV] [options] SOURCE DEST
+.SH OPTIONS
+.TP
+\fB\-U\fR, \fB\-\-noupdate\fR
+the clone will include an empty working directory (default: false)
+.TP
+\fB\-u\fR, \fB\-\-updaterev\fR \fIREV\fR
+revision to check out (required)
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Print this help and exit
+.SH CONSTRAINTS
+at most one of: \-\-noupdate, \-\-updaterev
+.SH ARGUMENTS
+.TP
+\fISOURCE\fR
+the source repository
+.TP
+\fIDEST\fR
+the destination directory
+.SH EXAMPLES
+.RS 4
+.nf
+hg clone https://example.org/repo
+hg clone \-U .
+.fi
+.RE
+.SH NOTES
+Returns 0 on success.
+.SH SEE ALSO
+\fBhg\fR(1)
+`
+	cli := newHgCLI(t)
+	dir := t.TempDir()
+
+	err := cli.WriteManPages(dir, manOpts)
+
+	rosina.AssertNoError(t, err)
+	entries, err := os.ReadDir(dir)
+	rosina.AssertNoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// The hidden subcommand has no man page.
+	rosina.AssertDeepEqual(t, names, []string{"hg-clone.1", "hg.1"}, "files")
+	have, err := os.ReadFile(filepath.Join(dir, "hg.1"))
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, string(have), wantHg, "hg.1")
+	have, err = os.ReadFile(filepath.Join(dir, "hg-clone.1"))
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, string(have), wantClone, "hg-clone.1")
+}
+
+func TestWriteManPageNested(t *testing.T) {
+	want := `.TH "BANG\-A" "8" "" "" ""
+.SH NAME
+bang\-a \- I am A
+.SH SYNOPSIS
+\fBbang a\fR [options] <command>
+.SH COMMANDS
+.SS Group
+.TP
+\fBb\fR
+I am \eB
+.SH OPTIONS
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Print this help and exit
+.SS Output
+.TP
+\fB\-\-json\fR
+\&.json output (default: false)
+.SH SEE ALSO
+\fBbang\fR(8),
+\fBbang\-a\-b\fR(8)
+`
+	var json bool
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	subA, err := clim.NewSub[any](cli, "a", "I am A", nil)
+	rosina.AssertNoError(t, err)
+	jsonFlag := &clim.Flag{
+		Value: clim.Bool(&json, false), Long: "json", Help: ".json output",
+	}
+	err = subA.AddFlags(jsonFlag)
+	rosina.AssertNoError(t, err)
+	err = subA.AddFlagGroup("Output", jsonFlag)
+	rosina.AssertNoError(t, err)
+	subB, err := clim.NewSub[any](subA, "b", `I am \B`, nil)
+	rosina.AssertNoError(t, err)
+	err = subA.AddGroup("Group", subB)
+	rosina.AssertNoError(t, err)
+
+	var bld strings.Builder
+	err = subA.WriteManPage(&bld, clim.ManOptions{Section: "8"})
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, bld.String(), want, "man page")
+}
+
+func TestWriteManPagesFailure(t *testing.T) {
+	cli, err := clim.NewTop[any]("hg", "Mercurial Distributed SCM", nil)
+	rosina.AssertNoError(t, err)
+
+	err = cli.WriteManPages(filepath.Join(t.TempDir(), "missing"), manOpts)
+
+	rosina.AssertErrorContains(t, err, "clim: man page: open ")
+}
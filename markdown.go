@@ -0,0 +1,274 @@
+// This file contains the export of the reference documentation, in Markdown
+// and HTML, from the command tree.
+
+package clim
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteMarkdown writes to directory 'dir' (which must exist) one Markdown
+// document per command, for cli and all its subcommands, recursively,
+// skipping the hidden ones. Each file is named after the command path, for
+// example "hg_clone.md". The output is deterministic, so that it can be
+// committed and diff-checked in CI.
+func (cli *CLI[T]) WriteMarkdown(dir string) error {
+	return cli.writeDocs(dir, ".md", func(w io.Writer, node *CLI[T]) error {
+		return node.WriteMarkdownPage(w)
+	})
+}
+
+// WriteHTML is like [CLI.WriteMarkdown], but writes HTML documents, for
+// example "hg_clone.html".
+func (cli *CLI[T]) WriteHTML(dir string) error {
+	return cli.writeDocs(dir, ".html", func(w io.Writer, node *CLI[T]) error {
+		return node.WriteHTMLPage(w)
+	})
+}
+
+func (cli *CLI[T]) writeDocs(dir string, ext string,
+	write func(w io.Writer, node *CLI[T]) error,
+) error {
+	for _, node := range cli.visibleTree() {
+		path := filepath.Join(dir, docName(node.rootToHere)+ext)
+		fi, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("clim: reference doc: %w", err)
+		}
+		if err := write(fi, node); err != nil {
+			fi.Close()
+			return err
+		}
+		if err := fi.Close(); err != nil {
+			return fmt.Errorf("clim: reference doc: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteMarkdownPage writes to 'w' the Markdown document of cli alone.
+// Links to the other commands assume the file names of [CLI.WriteMarkdown].
+func (cli *CLI[T]) WriteMarkdownPage(w io.Writer) error {
+	help := cli.help()
+	var bld strings.Builder
+
+	fmt.Fprintf(&bld, "# %s\n\n", help.Path)
+	if cli.parent != nil {
+		fmt.Fprintf(&bld, "Parent: [%s](%s.md)\n\n",
+			cli.parent.rootToHere, docName(cli.parent.rootToHere))
+	}
+	fmt.Fprintf(&bld, "%s\n\n", mdEscape(help.Oneline))
+	if help.Description != "" {
+		fmt.Fprintf(&bld, "%s\n\n", help.Description)
+	}
+
+	fmt.Fprintf(&bld, "## Usage\n\n```\n")
+	for _, line := range help.Synopsis {
+		fmt.Fprintf(&bld, "%s\n", line)
+	}
+	fmt.Fprintf(&bld, "```\n\n")
+
+	if help.Examples != "" {
+		fmt.Fprintf(&bld, "## Examples\n\n```\n%s\n```\n\n", help.Examples)
+	}
+
+	if len(help.Commands) > 0 {
+		fmt.Fprintf(&bld, "## Commands\n\n")
+		if len(help.Groups) > 0 {
+			for _, group := range help.Groups {
+				fmt.Fprintf(&bld, "### %s\n\n", mdEscape(group.Name))
+				cli.mdCommands(&bld, group.Commands)
+			}
+		} else {
+			cli.mdCommands(&bld, help.Commands)
+		}
+	}
+
+	fmt.Fprintf(&bld, "## Options\n\n")
+	mdFlags(&bld, append(help.Flags, HelpFlag{
+		Short: "h", Long: "help", Help: "Print this help and exit",
+	}))
+	for _, group := range help.FlagGroups {
+		fmt.Fprintf(&bld, "### %s\n\n", mdEscape(group.Name))
+		mdFlags(&bld, group.Flags)
+	}
+
+	if len(help.Constraints) > 0 {
+		fmt.Fprintf(&bld, "## Constraints\n\n")
+		for _, c := range help.Constraints {
+			fmt.Fprintf(&bld, "- %s\n", mdEscape(c))
+		}
+		fmt.Fprintf(&bld, "\n")
+	}
+
+	if len(help.Positionals) > 0 {
+		fmt.Fprintf(&bld, "## Positional arguments\n\n")
+		fmt.Fprintf(&bld, "| Name | Description |\n|------|-------------|\n")
+		for _, pa := range help.Positionals {
+			fmt.Fprintf(&bld, "| `%s` | %s |\n", pa.Name, mdCell(pa.Help))
+		}
+		fmt.Fprintf(&bld, "\n")
+	}
+
+	if help.Footer != "" {
+		fmt.Fprintf(&bld, "%s\n", help.Footer)
+	}
+
+	if _, err := io.WriteString(w, strings.TrimRight(bld.String(), "\n")+"\n"); err != nil {
+		return fmt.Errorf("clim: reference doc: %w", err)
+	}
+	return nil
+}
+
+func (cli *CLI[T]) mdCommands(bld *strings.Builder, commands []HelpCommand) {
+	fmt.Fprintf(bld, "| Command | Description |\n|---------|-------------|\n")
+	for _, cmd := range commands {
+		path := cli.rootToHere + " " + cmd.Name
+		fmt.Fprintf(bld, "| [%s](%s.md) | %s |\n",
+			cmd.Name, docName(path), mdCell(cmd.Oneline))
+	}
+	fmt.Fprintf(bld, "\n")
+}
+
+func mdFlags(bld *strings.Builder, flags []HelpFlag) {
+	fmt.Fprintf(bld, "| Option | Description |\n|--------|-------------|\n")
+	for _, flag := range flags {
+		fmt.Fprintf(bld, "| <a id=\"%s\"></a>`%s` | %s |\n",
+			"option-"+flag.Long, strings.TrimSpace(flag.Names()),
+			mdCell(flagDoc(flag)))
+	}
+	fmt.Fprintf(bld, "\n")
+}
+
+// WriteHTMLPage writes to 'w' the HTML document of cli alone.
+// Links to the other commands assume the file names of [CLI.WriteHTML].
+func (cli *CLI[T]) WriteHTMLPage(w io.Writer) error {
+	help := cli.help()
+	esc := html.EscapeString
+	var bld strings.Builder
+
+	fmt.Fprintf(&bld, "<!DOCTYPE html>\n<html>\n<head>\n")
+	fmt.Fprintf(&bld, "<meta charset=\"utf-8\">\n<title>%s</title>\n", esc(help.Path))
+	fmt.Fprintf(&bld, "</head>\n<body>\n")
+	fmt.Fprintf(&bld, "<h1 id=\"%s\">%s</h1>\n", docName(help.Path), esc(help.Path))
+	if cli.parent != nil {
+		fmt.Fprintf(&bld, "<p>Parent: <a href=\"%s.html\">%s</a></p>\n",
+			docName(cli.parent.rootToHere), esc(cli.parent.rootToHere))
+	}
+	fmt.Fprintf(&bld, "<p>%s</p>\n", esc(help.Oneline))
+	if help.Description != "" {
+		fmt.Fprintf(&bld, "<pre>%s</pre>\n", esc(help.Description))
+	}
+
+	fmt.Fprintf(&bld, "<h2 id=\"usage\">Usage</h2>\n<pre>")
+	fmt.Fprintf(&bld, "%s", esc(strings.Join(help.Synopsis, "\n")))
+	fmt.Fprintf(&bld, "</pre>\n")
+
+	if help.Examples != "" {
+		fmt.Fprintf(&bld, "<h2 id=\"examples\">Examples</h2>\n<pre>%s</pre>\n",
+			esc(help.Examples))
+	}
+
+	if len(help.Commands) > 0 {
+		fmt.Fprintf(&bld, "<h2 id=\"commands\">Commands</h2>\n")
+		if len(help.Groups) > 0 {
+			for _, group := range help.Groups {
+				fmt.Fprintf(&bld, "<h3>%s</h3>\n", esc(group.Name))
+				cli.htmlCommands(&bld, group.Commands)
+			}
+		} else {
+			cli.htmlCommands(&bld, help.Commands)
+		}
+	}
+
+	fmt.Fprintf(&bld, "<h2 id=\"options\">Options</h2>\n")
+	htmlFlags(&bld, append(help.Flags, HelpFlag{
+		Short: "h", Long: "help", Help: "Print this help and exit",
+	}))
+	for _, group := range help.FlagGroups {
+		fmt.Fprintf(&bld, "<h3>%s</h3>\n", esc(group.Name))
+		htmlFlags(&bld, group.Flags)
+	}
+
+	if len(help.Constraints) > 0 {
+		fmt.Fprintf(&bld, "<h2 id=\"constraints\">Constraints</h2>\n<ul>\n")
+		for _, c := range help.Constraints {
+			fmt.Fprintf(&bld, "<li>%s</li>\n", esc(c))
+		}
+		fmt.Fprintf(&bld, "</ul>\n")
+	}
+
+	if len(help.Positionals) > 0 {
+		fmt.Fprintf(&bld, "<h2 id=\"positional-arguments\">Positional arguments</h2>\n")
+		fmt.Fprintf(&bld, "<table>\n")
+		for _, pa := range help.Positionals {
+			fmt.Fprintf(&bld, "<tr><td><code>%s</code></td><td>%s</td></tr>\n",
+				esc(pa.Name), esc(pa.Help))
+		}
+		fmt.Fprintf(&bld, "</table>\n")
+	}
+
+	if help.Footer != "" {
+		fmt.Fprintf(&bld, "<p>%s</p>\n", esc(help.Footer))
+	}
+	fmt.Fprintf(&bld, "</body>\n</html>\n")
+
+	if _, err := io.WriteString(w, bld.String()); err != nil {
+		return fmt.Errorf("clim: reference doc: %w", err)
+	}
+	return nil
+}
+
+func (cli *CLI[T]) htmlCommands(bld *strings.Builder, commands []HelpCommand) {
+	fmt.Fprintf(bld, "<table>\n")
+	for _, cmd := range commands {
+		path := cli.rootToHere + " " + cmd.Name
+		fmt.Fprintf(bld,
+			"<tr><td><a href=\"%s.html\">%s</a></td><td>%s</td></tr>\n",
+			docName(path), html.EscapeString(cmd.Name),
+			html.EscapeString(cmd.Oneline))
+	}
+	fmt.Fprintf(bld, "</table>\n")
+}
+
+func htmlFlags(bld *strings.Builder, flags []HelpFlag) {
+	fmt.Fprintf(bld, "<table>\n")
+	for _, flag := range flags {
+		fmt.Fprintf(bld,
+			"<tr id=\"option-%s\"><td><code>%s</code></td><td>%s</td></tr>\n",
+			html.EscapeString(flag.Long),
+			html.EscapeString(strings.TrimSpace(flag.Names())),
+			html.EscapeString(flagDoc(flag)))
+	}
+	fmt.Fprintf(bld, "</table>\n")
+}
+
+// flagDoc returns the notes and the long help of 'flag', on one line.
+func flagDoc(flag HelpFlag) string {
+	return strings.Join(strings.Fields(flag.Notes()+" "+flag.LongHelp), " ")
+}
+
+// docName returns the base name (without extension) of the reference document
+// of the command with 'path'.
+func docName(path string) string {
+	return strings.ReplaceAll(path, " ", "_")
+}
+
+// mdEscape escapes the characters that would be interpreted as Markdown
+// inline formatting.
+func mdEscape(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+		"<", `\<`, ">", `\>`)
+	return replacer.Replace(text)
+}
+
+// mdCell escapes 'text' to be the content of a Markdown table cell.
+func mdCell(text string) string {
+	return strings.ReplaceAll(mdEscape(text), "|", `\|`)
+}
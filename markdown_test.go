@@ -0,0 +1,214 @@
+package clim_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	wantHg := "# hg\n" + `
+Mercurial Distributed SCM
+
+A distributed SCM.
+
+Fast and simple.
+
+## Usage
+
+` + "```" + `
+hg [options] <command>
+` + "```" + `
+
+## Commands
+
+| Command | Description |
+|---------|-------------|
+| [clone](hg_clone.md) | make a copy of an existing repository |
+
+## Options
+
+| Option | Description |
+|--------|-------------|
+| <a id="option-verbose"></a>` + "`-v, --verbose`" + ` | enable additional output (default: false) |
+| <a id="option-help"></a>` + "`-h, --help`" + ` | Print this help and exit |
+`
+	wantClone := "# hg clone\n" + `
+Parent: [hg](hg.md)
+
+make a copy of an existing repository
+
+## Usage
+
+` + "```" + `
+hg clone [--noupdate | --updaterev REV] [options] SOURCE DEST
+` + "```" + `
+
+## Examples
+
+` + "```" + `
+hg clone https://example.org/repo
+hg clone -U .
+` + "```" + `
+
+## Options
+
+| Option | Description |
+|--------|-------------|
+| <a id="option-noupdate"></a>` + "`-U, --noupdate`" + ` | the clone will include an empty working directory (default: false) |
+| <a id="option-updaterev"></a>` + "`-u, --updaterev REV`" + ` | revision to check out (required) |
+| <a id="option-help"></a>` + "`-h, --help`" + ` | Print this help and exit |
+
+## Constraints
+
+- at most one of: --noupdate, --updaterev
+
+## Positional arguments
+
+| Name | Description |
+|------|-------------|
+| ` + "`SOURCE`" + ` | the source repository |
+| ` + "`DEST`" + ` | the destination directory |
+
+Returns 0 on success.
+`
+	cli := newHgCLI(t)
+	dir := t.TempDir()
+
+	err := cli.WriteMarkdown(dir)
+
+	rosina.AssertNoError(t, err)
+	entries, err := os.ReadDir(dir)
+	rosina.AssertNoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// The hidden subcommand has no document.
+	rosina.AssertDeepEqual(t, names, []string{"hg.md", "hg_clone.md"}, "files")
+	have, err := os.ReadFile(filepath.Join(dir, "hg.md"))
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, string(have), wantHg, "hg.md")
+	have, err = os.ReadFile(filepath.Join(dir, "hg_clone.md"))
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, string(have), wantClone, "hg_clone.md")
+}
+
+func TestWriteMarkdownPageGroupsAndEscaping(t *testing.T) {
+	want := "# bang a\n" + `
+Parent: [bang](bang.md)
+
+I am \*A\*
+
+## Usage
+
+` + "```" + `
+bang a [options] <command>
+` + "```" + `
+
+## Commands
+
+### Group \_1
+
+| Command | Description |
+|---------|-------------|
+| [b](bang_a_b.md) | pipe \| here |
+
+## Options
+
+| Option | Description |
+|--------|-------------|
+| <a id="option-help"></a>` + "`-h, --help`" + ` | Print this help and exit |
+
+### Output
+
+| Option | Description |
+|--------|-------------|
+| <a id="option-json"></a>` + "`--json`" + ` | \<json\> output (default: false) |
+`
+	var json bool
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	subA, err := clim.NewSub[any](cli, "a", "I am *A*", nil)
+	rosina.AssertNoError(t, err)
+	jsonFlag := &clim.Flag{
+		Value: clim.Bool(&json, false), Long: "json", Help: "<json> output",
+	}
+	err = subA.AddFlags(jsonFlag)
+	rosina.AssertNoError(t, err)
+	err = subA.AddFlagGroup("Output", jsonFlag)
+	rosina.AssertNoError(t, err)
+	subB, err := clim.NewSub[any](subA, "b", "pipe | here", nil)
+	rosina.AssertNoError(t, err)
+	err = subA.AddGroup("Group _1", subB)
+	rosina.AssertNoError(t, err)
+
+	var bld strings.Builder
+	err = subA.WriteMarkdownPage(&bld)
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, bld.String(), want, "markdown")
+}
+
+func TestWriteHTML(t *testing.T) {
+	want := `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>hg clone</title>
+</head>
+<body>
+<h1 id="hg_clone">hg clone</h1>
+<p>Parent: <a href="hg.html">hg</a></p>
+<p>make a copy of an existing repository</p>
+<h2 id="usage">Usage</h2>
+<pre>hg clone [--noupdate | --updaterev REV] [options] SOURCE DEST</pre>
+<h2 id="examples">Examples</h2>
+<pre>hg clone https://example.org/repo
+hg clone -U .</pre>
+<h2 id="options">Options</h2>
+<table>
+<tr id="option-noupdate"><td><code>-U, --noupdate</code></td><td>the clone will include an empty working directory (default: false)</td></tr>
+<tr id="option-updaterev"><td><code>-u, --updaterev REV</code></td><td>revision to check out (required)</td></tr>
+<tr id="option-help"><td><code>-h, --help</code></td><td>Print this help and exit</td></tr>
+</table>
+<h2 id="constraints">Constraints</h2>
+<ul>
+<li>at most one of: --noupdate, --updaterev</li>
+</ul>
+<h2 id="positional-arguments">Positional arguments</h2>
+<table>
+<tr><td><code>SOURCE</code></td><td>the source repository</td></tr>
+<tr><td><code>DEST</code></td><td>the destination directory</td></tr>
+</table>
+<p>Returns 0 on success.</p>
+</body>
+</html>
+`
+	cli := newHgCLI(t)
+	dir := t.TempDir()
+
+	err := cli.WriteHTML(dir)
+
+	rosina.AssertNoError(t, err)
+	have, err := os.ReadFile(filepath.Join(dir, "hg_clone.html"))
+	rosina.AssertNoError(t, err)
+	rosina.AssertTextEqual(t, string(have), want, "hg_clone.html")
+	have, err = os.ReadFile(filepath.Join(dir, "hg.html"))
+	rosina.AssertNoError(t, err)
+	rosina.AssertContains(t, string(have),
+		`<tr><td><a href="hg_clone.html">clone</a></td>`)
+}
+
+func TestWriteMarkdownFailure(t *testing.T) {
+	cli, err := clim.NewTop[any]("hg", "Mercurial Distributed SCM", nil)
+	rosina.AssertNoError(t, err)
+
+	err = cli.WriteMarkdown(filepath.Join(t.TempDir(), "missing"))
+
+	rosina.AssertErrorContains(t, err, "clim: reference doc: open ")
+}
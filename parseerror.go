@@ -0,0 +1,268 @@
+// This file contains the structured parse error.
+
+package clim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseErrorKind is the kind of a [ParseError].
+type ParseErrorKind int
+
+const (
+	// KindOther is a parse error not covered by the other kinds.
+	KindOther ParseErrorKind = iota
+	// KindUnknownFlag is a flag not defined for the command.
+	KindUnknownFlag
+	// KindMissingValue is a flag given as last token, without its value.
+	KindMissingValue
+	// KindBadValue is a value rejected by [Value.Set].
+	KindBadValue
+	// KindInvalidValue is a value rejected by a validation hook; see
+	// [Flag.Validate] and [CLI.SetValidate].
+	KindInvalidValue
+	// KindMissingRequired is one or more required flags not given.
+	KindMissingRequired
+	// KindConstraint is a violated [Constraint] between flags.
+	KindConstraint
+	// KindMissingCommand is a missing subcommand.
+	KindMissingCommand
+	// KindUnknownCommand is a subcommand (or help topic) not defined.
+	KindUnknownCommand
+	// KindResponseFile is a response file that cannot be read or split.
+	// See [CLI.SetResponseFiles].
+	KindResponseFile
+	// KindArity is a number of positional arguments not matching their
+	// names (see [Pair] and [CLI.SetArityCheck]).
+	KindArity
+)
+
+// String returns the name of the kind, for example "unknown flag".
+func (k ParseErrorKind) String() string {
+	switch k {
+	case KindOther:
+		return "other"
+	case KindUnknownFlag:
+		return "unknown flag"
+	case KindMissingValue:
+		return "missing value"
+	case KindBadValue:
+		return "bad value"
+	case KindInvalidValue:
+		return "invalid value"
+	case KindMissingRequired:
+		return "missing required"
+	case KindConstraint:
+		return "constraint"
+	case KindMissingCommand:
+		return "missing command"
+	case KindUnknownCommand:
+		return "unknown command"
+	case KindResponseFile:
+		return "response file"
+	case KindArity:
+		return "positional arity"
+	default:
+		return fmt.Sprintf("ParseErrorKind(%d)", int(k))
+	}
+}
+
+// ParseError is the error returned by [CLI.Parse] when the command-line is
+// wrong. It satisfies errors.Is(err, ErrParse); retrieve it with errors.As:
+//
+//	var perr *clim.ParseError
+//	if errors.As(err, &perr) {
+//	    fmt.Println(perr.Kind, perr.Index, perr.Token)
+//	}
+type ParseError struct {
+	Kind ParseErrorKind
+	// Token is the offending token of the command-line, if any.
+	Token string
+	// Index is the index of Token in the args passed to [CLI.Parse] (after
+	// the expansion of the response files, if any), or -1 if the error is not
+	// related to a token, as in a missing required flag. For KindResponseFile,
+	// it is the index of the @file argument in the args of [CLI.Parse] (for a
+	// nested response file, of the top-level one).
+	Index int
+	// Path is the path of the command, for example "hg clone".
+	Path string
+	// Flag is the long name of the flag, if any. For KindMissingRequired, it
+	// is the first (in alphabetical order) of the missing flags.
+	Flag string
+	// Err is the underlying error, if any, for example the one returned by
+	// [Value.Set] or by a validation hook.
+	Err  error
+	msg  string
+	hint string // See CLI.SetUsageHint.
+}
+
+// Error implements the error interface.
+func (pe *ParseError) Error() string {
+	return pe.msg + pe.hint
+}
+
+// Is reports whether 'target' is [ErrParse].
+func (pe *ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+// Unwrap returns the underlying error, if any.
+func (pe *ParseError) Unwrap() error {
+	return pe.Err
+}
+
+// SetUsageHint enables (or disables) the usage hint: the message of a
+// [ParseError] returned by [CLI.Parse] is followed by the synopsis of the
+// failing command and by a line suggesting how to get its help, as in:
+//
+//	missing required options: foo
+//	Usage: prog sub --foo FOO [options]
+//	Run 'prog sub --help' for usage.
+//
+// With [CLI.SetReportAll], the hint follows all the errors.
+func (cli *CLI[T]) SetUsageHint(enable bool) {
+	cli.rootOnly("SetUsageHint")
+	cli.usageHint = enable
+}
+
+// SetReportAll enables (or disables) the report of all the parse errors:
+// instead of stopping at the first error, [CLI.Parse] continues parsing and
+// returns all the errors joined with [errors.Join]: bad flags, missing values,
+// missing required options, violated constraints and positional arity (if
+// enabled with [CLI.SetArityCheck]). Each
+// one is a [ParseError]; iterate over them with:
+//
+//	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+//	    for _, e := range joined.Unwrap() { ... }
+//	}
+//
+// A single error is returned as-is. The validation hook of a command (see
+// [CLI.SetValidate]) is called only if there are no errors.
+func (cli *CLI[T]) SetReportAll(enable bool) {
+	cli.rootOnly("SetReportAll")
+	cli.reportAll = enable
+}
+
+// parseErrors collects the errors of [CLI.parse].
+type parseErrors struct {
+	all  bool // See SetReportAll.
+	errs []error
+}
+
+// add records 'err' and returns true if parsing must stop: always, unless
+// reporting all the errors. An error that is not a [ParseError] (for
+// example, the help) replaces the errors recorded so far.
+func (pe *parseErrors) add(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		pe.errs = append(pe.errs, joined.Unwrap()...)
+		return !pe.all
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		pe.errs = []error{err}
+		return true
+	}
+	pe.errs = append(pe.errs, err)
+	return !pe.all
+}
+
+// err returns the recorded errors, joined, or nil.
+func (pe *parseErrors) err() error {
+	switch len(pe.errs) {
+	case 0:
+		return nil
+	case 1:
+		return pe.errs[0]
+	default:
+		return errors.Join(pe.errs...)
+	}
+}
+
+// addUsageHint returns 'err' followed by the usage hint, if it is (or joins)
+// a [ParseError] of a command of the tree of cli. With [CLI.SetReportAll], the
+// hint is added once, after all the errors.
+func (cli *CLI[T]) addUsageHint(err error) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return err
+	}
+	node := cli.findPath(pe.Path)
+	if node == nil {
+		return err
+	}
+	var bld strings.Builder
+	for i, line := range node.synopsis() {
+		if i == 0 {
+			fmt.Fprintf(&bld, "\nUsage: %s", line)
+		} else {
+			fmt.Fprintf(&bld, "\n       %s", line)
+		}
+	}
+	fmt.Fprintf(&bld, "\nRun '%s --help' for usage.", node.rootToHere)
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return &hintedErrors{errs: joined.Unwrap(), text: err.Error(), hint: bld.String()}
+	}
+	pe.hint = bld.String()
+	return err
+}
+
+// hintedErrors is the joined errors of [CLI.SetReportAll], followed by the
+// usage hint.
+type hintedErrors struct {
+	errs []error
+	text string
+	hint string
+}
+
+// Error implements the error interface.
+func (he *hintedErrors) Error() string {
+	return he.text + he.hint
+}
+
+// Unwrap returns the joined errors.
+func (he *hintedErrors) Unwrap() []error {
+	return he.errs
+}
+
+// findPath returns the node of the tree of cli with 'path', or nil.
+func (cli *CLI[T]) findPath(path string) *CLI[T] {
+	if cli.rootToHere == path {
+		return cli
+	}
+	for _, sub := range cli.subCLIs {
+		if node := sub.findPath(path); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// newParseError creates a [ParseError] not related to a token.
+func (cli *CLI[T]) newParseError(kind ParseErrorKind, format string, a ...any,
+) *ParseError {
+	return &ParseError{
+		Kind:  kind,
+		Index: -1,
+		Path:  cli.rootToHere,
+		msg:   fmt.Sprintf(format, a...),
+	}
+}
+
+// newTokenError creates a [ParseError] related to 'token', at 'index'.
+func (cli *CLI[T]) newTokenError(kind ParseErrorKind, token string, index int,
+	format string, a ...any,
+) *ParseError {
+	pe := cli.newParseError(kind, format, a...)
+	pe.Token = token
+	pe.Index = index
+	return pe
+}
+
+// with sets the flag and the underlying error of pe, and returns it.
+func (pe *ParseError) with(flag string, err error) *ParseError {
+	pe.Flag = flag
+	pe.Err = err
+	return pe
+}
@@ -0,0 +1,321 @@
+package clim_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestParseError(t *testing.T) {
+	type testCase struct {
+		name string
+		args []string
+		want clim.ParseError
+	}
+
+	errTooBig := errors.New("too big")
+
+	test := func(t *testing.T, tc testCase) {
+		var count, level int
+		var force bool
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(
+			&clim.Flag{Value: clim.Bool(&force, false), Long: "force"},
+			&clim.Flag{
+				Value: clim.Int(&level, 0), Long: "level",
+				Validate: func() error {
+					if level > 9 {
+						return errTooBig
+					}
+					return nil
+				},
+			})
+		rosina.AssertNoError(t, err)
+		sub, err := clim.NewSub[any](cli, "sub", "I am sub", nil)
+		rosina.AssertNoError(t, err)
+		err = sub.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 0), Short: "c", Long: "count",
+			Required: true,
+		})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		var perr *clim.ParseError
+		rosina.AssertEqual(t, errors.As(err, &perr), true, "errors.As")
+		rosina.AssertEqual(t, perr.Kind, tc.want.Kind, "kind")
+		rosina.AssertEqual(t, perr.Token, tc.want.Token, "token")
+		rosina.AssertEqual(t, perr.Index, tc.want.Index, "index")
+		rosina.AssertEqual(t, perr.Path, tc.want.Path, "path")
+		rosina.AssertEqual(t, perr.Flag, tc.want.Flag, "flag")
+		if tc.want.Err != nil {
+			rosina.AssertErrorIs(t, err, tc.want.Err)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name: "unknown flag",
+			args: []string{"--force", "--banana"},
+			want: clim.ParseError{
+				Kind: clim.KindUnknownFlag, Token: "--banana", Index: 1,
+				Path: "bang",
+			},
+		},
+		{
+			name: "unknown flag of subcommand",
+			args: []string{"--force", "sub", "-c", "1", "-x"},
+			want: clim.ParseError{
+				Kind: clim.KindUnknownFlag, Token: "-x", Index: 4,
+				Path: "bang sub",
+			},
+		},
+		{
+			name: "missing value",
+			args: []string{"sub", "--count"},
+			want: clim.ParseError{
+				Kind: clim.KindMissingValue, Token: "--count", Index: 1,
+				Path: "bang sub", Flag: "count",
+			},
+		},
+		{
+			name: "bad value",
+			args: []string{"sub", "-c", "x"},
+			want: clim.ParseError{
+				Kind: clim.KindBadValue, Token: "-c", Index: 1,
+				Path: "bang sub", Flag: "count",
+			},
+		},
+		{
+			name: "invalid value",
+			args: []string{"--level=10", "sub"},
+			want: clim.ParseError{
+				Kind: clim.KindInvalidValue, Token: "--level=10", Index: 0,
+				Path: "bang", Flag: "level", Err: errTooBig,
+			},
+		},
+		{
+			name: "missing required",
+			args: []string{"sub"},
+			want: clim.ParseError{
+				Kind: clim.KindMissingRequired, Index: -1,
+				Path: "bang sub", Flag: "count",
+			},
+		},
+		{
+			name: "missing command",
+			args: []string{"--force"},
+			want: clim.ParseError{
+				Kind: clim.KindMissingCommand, Index: -1, Path: "bang",
+			},
+		},
+		{
+			name: "unknown command",
+			args: []string{"--force", "banana"},
+			want: clim.ParseError{
+				Kind: clim.KindUnknownCommand, Token: "banana", Index: 1,
+				Path: "bang",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestParseErrorOfConstraint(t *testing.T) {
+	var aa, bb bool
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(
+		&clim.Flag{Value: clim.Bool(&aa, false), Long: "aa"},
+		&clim.Flag{Value: clim.Bool(&bb, false), Long: "bb"})
+	rosina.AssertNoError(t, err)
+	err = cli.AddConstraints(clim.AtMostOne("aa", "bb"))
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--aa", "--bb"})
+
+	var perr *clim.ParseError
+	rosina.AssertEqual(t, errors.As(err, &perr), true, "errors.As")
+	rosina.AssertEqual(t, perr.Kind, clim.KindConstraint, "kind")
+	rosina.AssertEqual(t, perr.Path, "bang", "path")
+	rosina.AssertEqual(t, perr.Error(), "only one of --aa, --bb can be specified",
+		"message")
+}
+
+func TestParseErrorKindString(t *testing.T) {
+	rosina.AssertEqual(t, clim.KindUnknownFlag.String(), "unknown flag", "kind")
+	rosina.AssertEqual(t, clim.ParseErrorKind(99).String(), "ParseErrorKind(99)",
+		"kind")
+}
+
+func TestUsageHint(t *testing.T) {
+	type testCase struct {
+		name      string
+		usageHint bool
+		args      []string
+		want      string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetUsageHint(tc.usageHint)
+		sub, err := clim.NewSub[any](cli, "sub", "I am sub", nil)
+		rosina.AssertNoError(t, err)
+		err = sub.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 0), Long: "count", Required: true,
+		})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertTextEqual(t, err.Error(), tc.want, "error message")
+	}
+
+	testCases := []testCase{
+		{
+			name: "disabled",
+			args: []string{"sub"},
+			want: "missing required options: count",
+		},
+		{
+			name:      "enabled, top-level",
+			usageHint: true,
+			args:      []string{"banana"},
+			want: `unrecognized command "banana"
+Usage: bang [options] <command>
+Run 'bang --help' for usage.`,
+		},
+		{
+			name:      "enabled, subcommand",
+			usageHint: true,
+			args:      []string{"sub"},
+			want: `missing required options: count
+Usage: bang sub --count COUNT [options]
+Run 'bang sub --help' for usage.`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestReportAll(t *testing.T) {
+	type testCase struct {
+		name      string
+		reportAll bool
+		usageHint bool
+		args      []string
+		wantKinds []clim.ParseErrorKind
+		wantErr   string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var count, level int
+		var aa, bb bool
+		var names []string
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetReportAll(tc.reportAll)
+		cli.SetUsageHint(tc.usageHint)
+		cli.SetArityCheck(true)
+		err = cli.AddFlags(
+			&clim.Flag{Value: clim.Int(&level, 0), Long: "level"},
+			&clim.Flag{Value: clim.Bool(&aa, false), Long: "aa"},
+			&clim.Flag{Value: clim.Bool(&bb, false), Long: "bb"})
+		rosina.AssertNoError(t, err)
+		err = cli.AddConstraints(clim.AtMostOne("aa", "bb"))
+		rosina.AssertNoError(t, err)
+		sub, err := clim.NewSub[any](cli, "sub", "I am sub", nil)
+		rosina.AssertNoError(t, err)
+		err = sub.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 0), Long: "count", Required: true,
+		})
+		rosina.AssertNoError(t, err)
+		err = sub.AddPosArgs(&names, clim.Pair{"NAME", "The name"})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertTextEqual(t, err.Error(), tc.wantErr, "error message")
+		var errs []error
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		} else {
+			errs = []error{err}
+		}
+		var kinds []clim.ParseErrorKind
+		for _, e := range errs {
+			var perr *clim.ParseError
+			rosina.AssertEqual(t, errors.As(e, &perr), true, "errors.As")
+			kinds = append(kinds, perr.Kind)
+		}
+		rosina.AssertDeepEqual(t, kinds, tc.wantKinds, "kinds")
+	}
+
+	args := []string{
+		"--banana", "--level", "x", "--aa", "--bb", "sub", "--count", "y",
+	}
+
+	testCases := []testCase{
+		{
+			name:      "disabled",
+			args:      args,
+			wantKinds: []clim.ParseErrorKind{clim.KindUnknownFlag},
+			wantErr:   `unrecognized flag "--banana"`,
+		},
+		{
+			name:      "enabled",
+			reportAll: true,
+			args:      args,
+			wantKinds: []clim.ParseErrorKind{
+				clim.KindUnknownFlag, clim.KindBadValue, clim.KindConstraint,
+				clim.KindBadValue, clim.KindArity,
+			},
+			wantErr: `unrecognized flag "--banana"
+setting "--level" "x": could not parse "x" as int (strconv.ParseInt: parsing "x": invalid syntax)
+only one of --aa, --bb can be specified
+setting "--count" "y": could not parse "y" as int (strconv.ParseInt: parsing "y": invalid syntax)
+missing positional arguments: NAME`,
+		},
+		{
+			name:      "enabled, with usage hint",
+			reportAll: true,
+			usageHint: true,
+			args:      []string{"--banana", "--aa", "--bb"},
+			wantKinds: []clim.ParseErrorKind{
+				clim.KindUnknownFlag, clim.KindConstraint, clim.KindMissingCommand,
+			},
+			wantErr: `unrecognized flag "--banana"
+only one of --aa, --bb can be specified
+expected a command
+Usage: bang [--aa | --bb] [options] <command>
+Run 'bang --help' for usage.`,
+		},
+		{
+			name:      "enabled, missing command",
+			reportAll: true,
+			args:      []string{"--banana"},
+			wantKinds: []clim.ParseErrorKind{
+				clim.KindUnknownFlag, clim.KindMissingCommand,
+			},
+			wantErr: `unrecognized flag "--banana"
+expected a command`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
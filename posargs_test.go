@@ -99,3 +99,96 @@ func TestAddPosArgFailure(t *testing.T) {
 // 	// assing and check assignment
 // 	t.Fatal("writeme")
 // }
+
+func TestPosArgsArity(t *testing.T) {
+	type testCase struct {
+		name    string
+		pairs   []clim.Pair
+		args    []string
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetArityCheck(true)
+		var positionals []string
+		err = cli.AddPosArgs(&positionals, tc.pairs...)
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		if tc.wantErr == "" {
+			rosina.AssertNoError(t, err)
+			rosina.AssertDeepEqual(t, positionals, tc.args, "positionals")
+			return
+		}
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertEqual(t, err.Error(), tc.wantErr, "error message")
+	}
+
+	testCases := []testCase{
+		{
+			name:  "exact",
+			pairs: []clim.Pair{{"SRC", ""}, {"DST", ""}},
+			args:  []string{"a", "b"},
+		},
+		{
+			name:    "missing",
+			pairs:   []clim.Pair{{"SRC", ""}, {"DST", ""}},
+			args:    []string{"a"},
+			wantErr: "missing positional arguments: DST",
+		},
+		{
+			name:    "too many",
+			pairs:   []clim.Pair{{"SRC", ""}, {"DST", ""}},
+			args:    []string{"a", "b", "c", "d"},
+			wantErr: "too many positional arguments: c d",
+		},
+		{
+			name:  "optional, absent",
+			pairs: []clim.Pair{{"SRC", ""}, {"[DST]", ""}},
+			args:  []string{"a"},
+		},
+		{
+			name:    "optional, too many",
+			pairs:   []clim.Pair{{"SRC", ""}, {"[DST]", ""}},
+			args:    []string{"a", "b", "c"},
+			wantErr: "too many positional arguments: c",
+		},
+		{
+			name:  "one or more",
+			pairs: []clim.Pair{{"SRC...", ""}, {"DST", ""}},
+			args:  []string{"a", "b", "c"},
+		},
+		{
+			name:    "one or more, missing",
+			pairs:   []clim.Pair{{"SRC...", ""}, {"DST", ""}},
+			args:    []string{},
+			wantErr: "missing positional arguments: SRC... DST",
+		},
+		{
+			name:  "zero or more",
+			pairs: []clim.Pair{{"[SRC...]", ""}},
+			args:  []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestPosArgsArityDisabledByDefault(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	var positionals []string
+	err = cli.AddPosArgs(&positionals,
+		clim.Pair{"NAME", "The name"}, clim.Pair{"COUNT", "The count"})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"a"})
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertDeepEqual(t, positionals, []string{"a"}, "positionals")
+}
@@ -0,0 +1,336 @@
+// This file contains the renderers of the help. See also Help.
+
+package clim
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// HelpRenderer renders the data model of the help of a command.
+// See [CLI.SetHelpRenderer], [TextRenderer] and [TemplateRenderer].
+type HelpRenderer interface {
+	Render(w io.Writer, help *Help) error
+}
+
+// optionsGutter is the minimum space between the two columns of the options.
+const optionsGutter = 4
+
+// minWrapWidth is the minimum width of the wrapped second column. Below it,
+// wrapping would make the help less readable, not more.
+const minWrapWidth = 20
+
+// TextRenderer is the default [HelpRenderer]. It renders the help as plain
+// text, with two-column lists of commands, options and positional arguments.
+// If [Help.Color] is true, it styles the help with ANSI escape sequences.
+type TextRenderer struct{}
+
+// Render implements [HelpRenderer].
+func (TextRenderer) Render(w io.Writer, help *Help) error {
+	var bld strings.Builder
+	st := styler(help.Color)
+
+	fmt.Fprintf(&bld, "%s -- %s\n\n", st.name(help.Path), help.Oneline)
+
+	if help.Description != "" {
+		for _, line := range strings.Split(help.Description, "\n") {
+			fmt.Fprintf(&bld, " %s\n", line)
+		}
+		fmt.Fprintln(&bld)
+	}
+
+	for i, line := range help.Synopsis {
+		if i == 0 {
+			fmt.Fprintf(&bld, "%s %s\n", st.heading("Usage:"), line)
+		} else {
+			fmt.Fprintf(&bld, "       %s\n", line)
+		}
+	}
+	fmt.Fprintln(&bld)
+
+	if help.Examples != "" {
+		fmt.Fprintf(&bld, "%s\n\n", st.heading("Examples:"))
+		for _, line := range strings.Split(help.Examples, "\n") {
+			if line != "" {
+				fmt.Fprintf(&bld, " %s\n", line)
+			} else {
+				fmt.Fprintln(&bld)
+			}
+		}
+		fmt.Fprintln(&bld)
+	}
+
+	width := commandsWidth(help)
+	renderCommands(&bld, st, width, help)
+	renderTopics(&bld, st, width, help)
+	renderOptions(&bld, st, help)
+	renderConstraints(&bld, st, help)
+	renderPosArgs(&bld, st, help)
+
+	if !help.Full {
+		fmt.Fprintf(&bld, "\nUse \"%s --help\" for the full help.\n", help.Path)
+	}
+
+	if help.Footer != "" {
+		fmt.Fprintln(&bld)
+		for _, line := range strings.Split(help.Footer, "\n") {
+			fmt.Fprintf(&bld, " %s\n", line)
+		}
+	}
+
+	_, err := io.WriteString(w, bld.String())
+	return err
+}
+
+// commandsWidth returns the width of the first column of the commands and of
+// the help topics, so that they line up.
+func commandsWidth(help *Help) int {
+	maxColWidth := 0
+	for _, cmd := range help.Commands {
+		maxColWidth = max(maxColWidth, 1+len(cmd.Name))
+	}
+	for _, topic := range help.Topics {
+		maxColWidth = max(maxColWidth, 1+len(topic.Name))
+	}
+	const gutter = 4
+	return maxColWidth + gutter
+}
+
+func renderCommands(bld *strings.Builder, st styler, width int, help *Help) {
+	if len(help.Commands) == 0 {
+		return
+	}
+
+	// Render the commands, per group.
+	if len(help.Groups) > 0 {
+		fmt.Fprintf(bld, "%s\n\n", st.heading("available commands:"))
+		for _, group := range help.Groups {
+			fmt.Fprintf(bld, "%s\n\n", st.heading(group.Name+":"))
+			renderSomeCommands(bld, st, width, help.Width, group.Commands)
+		}
+	} else {
+		fmt.Fprintf(bld, "%s\n\n", st.heading("Commands:"))
+		renderSomeCommands(bld, st, width, help.Width, help.Commands)
+	}
+}
+
+func renderTopics(bld *strings.Builder, st styler, width int, help *Help) {
+	if len(help.Topics) > 0 {
+		fmt.Fprintf(bld, "%s\n\n", st.heading("Help topics:"))
+		renderSomeCommands(bld, st, width, help.Width, help.Topics)
+	}
+	if help.HelpCommand {
+		what := "<command>"
+		switch {
+		case len(help.Commands) > 0 && len(help.Topics) > 0:
+			what = "<command|topic>"
+		case len(help.Topics) > 0:
+			what = "<topic>"
+		}
+		fmt.Fprintf(bld, "Use \"%s help %s\" for more information.\n\n",
+			help.Path, what)
+	}
+}
+
+func renderSomeCommands(bld *strings.Builder, st styler, width int,
+	helpWidth int, commands []HelpCommand,
+) {
+	for _, cmd := range commands {
+		fmt.Fprintf(bld, " %s%s\n", pad(st.name(cmd.Name), cmd.Name, width),
+			wrapText(cmd.Oneline, 1+width, helpWidth))
+	}
+	fmt.Fprintln(bld)
+}
+
+func renderOptions(bld *strings.Builder, st styler, help *Help) {
+	// Calculate the max width of the first column, across all the groups,
+	// so that all the options are aligned.
+	helpLine := " -h, --help"
+	maxColWidth := len(helpLine)
+	var firstCols []string
+	for _, flag := range help.Flags {
+		firstCols = append(firstCols, " "+flag.Names())
+	}
+	for _, group := range help.FlagGroups {
+		for _, flag := range group.Flags {
+			firstCols = append(firstCols, " "+flag.Names())
+		}
+	}
+	for _, col := range firstCols {
+		maxColWidth = max(maxColWidth, len(col))
+	}
+	width := maxColWidth + optionsGutter
+
+	// Honor the max column, if any. The first columns that are too wide
+	// will be on their own line.
+	if help.MaxColumn > 0 && width > help.MaxColumn {
+		maxColWidth = len(helpLine)
+		for _, col := range firstCols {
+			if len(col)+optionsGutter <= help.MaxColumn {
+				maxColWidth = max(maxColWidth, len(col))
+			}
+		}
+		width = maxColWidth + optionsGutter
+	}
+
+	// Add the second column. The flags not belonging to any group go first.
+	fmt.Fprintf(bld, "%s\n\n", st.heading("Options:"))
+	for _, flag := range help.Flags {
+		renderFlag(bld, st, width, help.Width, flag)
+	}
+	if len(help.Flags) > 0 {
+		fmt.Fprintf(bld, "\n")
+	}
+
+	fmt.Fprintf(bld, "%s%s", pad(" "+st.name("-h")+", "+st.name("--help"),
+		helpLine, width), "Print this help and exit\n")
+
+	for _, group := range help.FlagGroups {
+		fmt.Fprintf(bld, "\n%s\n\n", st.heading(group.Name+":"))
+		for _, flag := range group.Flags {
+			renderFlag(bld, st, width, help.Width, flag)
+		}
+	}
+}
+
+// renderFlag renders the help line of 'flag'; the first column is padded to
+// 'width' and the second column is wrapped to 'helpWidth'.
+func renderFlag(bld *strings.Builder, st styler, width int, helpWidth int,
+	flag HelpFlag,
+) {
+	firstCol := " " + flag.Names()
+	styled := " " + styledNames(st, flag)
+	if len(firstCol)+optionsGutter > width {
+		// Too wide, see SetHelpMaxColumn.
+		fmt.Fprintf(bld, "%s\n%*s", styled, width, "")
+	} else {
+		fmt.Fprintf(bld, "%s", pad(styled, firstCol, width))
+	}
+	fmt.Fprintf(bld, "%s\n", wrapText(flag.Notes(), width, helpWidth))
+	for _, line := range strings.Split(flag.LongHelp, "\n") {
+		if line != "" {
+			fmt.Fprintf(bld, "%*s%s\n", width, "", wrapText(line, width, helpWidth))
+		}
+	}
+}
+
+// styledNames is like [HelpFlag.Names], styled.
+func styledNames(st styler, hf HelpFlag) string {
+	var bld strings.Builder
+	if hf.Short != "" {
+		fmt.Fprintf(&bld, "%s, ", st.name("-"+hf.Short))
+	}
+	fmt.Fprintf(&bld, "%s %s", st.name("--"+hf.Long), st.placeholder(hf.Label))
+	return bld.String()
+}
+
+func renderConstraints(bld *strings.Builder, st styler, help *Help) {
+	if len(help.Constraints) == 0 {
+		return
+	}
+	fmt.Fprintln(bld)
+	fmt.Fprintf(bld, "%s\n\n", st.heading("Constraints:"))
+	for _, c := range help.Constraints {
+		fmt.Fprintf(bld, " %s\n", c)
+	}
+}
+
+func renderPosArgs(bld *strings.Builder, st styler, help *Help) {
+	if len(help.Positionals) == 0 {
+		return
+	}
+
+	// First pass, calculate the max width of the first column.
+	maxColWidth := 0
+	for _, pa := range help.Positionals {
+		maxColWidth = max(maxColWidth, len(pa.Name))
+	}
+
+	// Second pass, consider the second column.
+	fmt.Fprintln(bld)
+	const gutter = 6
+	fmt.Fprintf(bld, "%s\n\n", st.heading("Positional arguments:"))
+	width := maxColWidth + gutter
+	for _, pa := range help.Positionals {
+		fmt.Fprintf(bld, " %s%s\n", pad(st.placeholder(pa.Name), pa.Name, width),
+			wrapText(pa.Help, 1+width, help.Width))
+	}
+}
+
+// TemplateRenderer is a [HelpRenderer] based on text/template. The template
+// is executed with the [Help] as data, and can use the following functions
+// in addition to the predefined ones:
+//
+//	wrap TEXT INDENT WIDTH  wraps TEXT (see below)
+//	pad TEXT N              pads TEXT with spaces to N columns
+//	indent N TEXT           indents each line of TEXT by N spaces
+//	lines TEXT              splits TEXT into lines
+//
+// Function wrap wraps TEXT at word boundaries so that it fits in WIDTH
+// columns when starting at column INDENT, indenting the continuation lines
+// by INDENT spaces. Pass .Width as WIDTH to honor [CLI.SetHelpWidth].
+type TemplateRenderer struct {
+	tmpl *template.Template
+}
+
+// NewTemplateRenderer creates a [TemplateRenderer] from the template 'text'.
+func NewTemplateRenderer(text string) (*TemplateRenderer, error) {
+	funcs := template.FuncMap{
+		"wrap": func(text string, indent int, width int) string {
+			return wrapText(text, indent, width)
+		},
+		"pad": func(text string, n int) string {
+			return fmt.Sprintf("%-*s", n, text)
+		},
+		"indent": func(n int, text string) string {
+			prefix := strings.Repeat(" ", n)
+			return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+		},
+		"lines": func(text string) []string {
+			return strings.Split(text, "\n")
+		},
+	}
+	tmpl, err := template.New("help").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("clim: help template: %w", err)
+	}
+	return &TemplateRenderer{tmpl: tmpl}, nil
+}
+
+// Render implements [HelpRenderer].
+func (tr *TemplateRenderer) Render(w io.Writer, help *Help) error {
+	return tr.tmpl.Execute(w, help)
+}
+
+// wrapText wraps 'text' at word boundaries so that it fits in 'width' columns
+// when starting at column 'indent'. The continuation lines are indented by
+// 'indent' spaces; the first line is not, since the caller has already printed
+// the first column. A 'width' <= 0 disables wrapping.
+func wrapText(text string, indent int, width int) string {
+	if width <= 0 {
+		return text
+	}
+	avail := max(width-indent, minWrapWidth)
+	if len(text) <= avail {
+		return text
+	}
+
+	var bld strings.Builder
+	lineLen := 0
+	for _, word := range strings.Fields(text) {
+		switch {
+		case lineLen == 0:
+		case lineLen+1+len(word) > avail:
+			fmt.Fprintf(&bld, "\n%*s", indent, "")
+			lineLen = 0
+		default:
+			bld.WriteByte(' ')
+			lineLen++
+		}
+		bld.WriteString(word)
+		lineLen += len(word)
+	}
+	return bld.String()
+}
@@ -0,0 +1,220 @@
+package clim_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+// spyRenderer is a HelpRenderer that records the Help it receives.
+type spyRenderer struct {
+	help *clim.Help
+}
+
+func (spy *spyRenderer) Render(w io.Writer, help *clim.Help) error {
+	spy.help = help
+	_, err := io.WriteString(w, "spy")
+	return err
+}
+
+func TestHelpModel(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	var spy spyRenderer
+	cli.SetHelpRenderer(&spy)
+	cli.SetHelpWidth(70)
+	subA, err := clim.NewSub[any](cli, "aa", "I am A", nil)
+	rosina.AssertNoError(t, err)
+	subB, err := clim.NewSub[any](cli, "bb", "I am B", nil)
+	rosina.AssertNoError(t, err)
+	subB.SetHidden(true)
+	err = cli.AddGroup("Group", subA, subB)
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertEqual(t, err.Error(), "spy", "help message")
+	want := &clim.Help{
+		Name:     "bang",
+		Path:     "bang",
+		Oneline:  "bang head",
+		Synopsis: []string{"bang [options] <command>"},
+		Commands: []clim.HelpCommand{{Name: "aa", Oneline: "I am A"}},
+		Groups: []clim.HelpCommandGroup{
+			{
+				Name:     "Group",
+				Commands: []clim.HelpCommand{{Name: "aa", Oneline: "I am A"}},
+			},
+		},
+		Width: 70,
+		Full:  true,
+	}
+	rosina.AssertDeepEqual(t, spy.help, want, "help model")
+}
+
+func TestHelpModelOfFlags(t *testing.T) {
+	var count, secret int
+	var verbose bool
+	var positionals []string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetDescription("The description.")
+	cli.SetExamples("bang sub -c 3")
+	cli.SetFooter("The footer.")
+	cli.SetHelpWidth(70)
+	cli.SetHelpMaxColumn(30)
+	verboseFlag := &clim.Flag{
+		Value: clim.Bool(&verbose, false), Short: "v", Long: "verbose",
+		Help: "Be verbose", Env: "BANG_VERBOSE",
+	}
+	err = cli.AddFlags(
+		&clim.Flag{
+			Value: clim.Int(&count, 3), Short: "c", Long: "count", Label: "N",
+			Help: "How many times", Required: true,
+		},
+		&clim.Flag{
+			Value: clim.Int(&secret, 0), Long: "secret", Hidden: true,
+		},
+		verboseFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlagGroup("Output", verboseFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddConstraints(clim.Requires("verbose", "count"))
+	rosina.AssertNoError(t, err)
+	err = cli.AddPosArgs(&positionals, clim.Pair{"NAME", "The name"})
+	rosina.AssertNoError(t, err)
+	var spy spyRenderer
+	cli.SetHelpRenderer(&spy)
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	want := &clim.Help{
+		Name:        "bang",
+		Path:        "bang",
+		Oneline:     "bang head",
+		Description: "The description.",
+		Synopsis:    []string{"bang --count N [options] NAME"},
+		Examples:    "bang sub -c 3",
+		Flags: []clim.HelpFlag{
+			{
+				Short: "c", Long: "count", Label: "N", Help: "How many times",
+				Required: true,
+			},
+		},
+		FlagGroups: []clim.HelpFlagGroup{
+			{
+				Name: "Output",
+				Flags: []clim.HelpFlag{
+					{
+						Short: "v", Long: "verbose", Help: "Be verbose",
+						Default: "false", Env: "BANG_VERBOSE",
+					},
+				},
+			},
+		},
+		Constraints: []string{"--verbose requires: --count"},
+		Positionals: []clim.HelpPosArg{{Name: "NAME", Help: "The name"}},
+		Footer:      "The footer.",
+		Width:       70,
+		MaxColumn:   30,
+		Full:        true,
+	}
+	rosina.AssertDeepEqual(t, spy.help, want, "help model")
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	const tmpl = `{{.Path}}: {{.Oneline}}
+
+USAGE
+{{range .Synopsis}}  {{.}}
+{{end}}
+OPTIONS
+{{range .Flags}}  {{pad .Names 16}}{{wrap .Notes 18 $.Width}}
+{{end}}{{range .FlagGroups}}
+{{.Name}}
+{{range .Flags}}  {{pad .Names 16}}{{wrap .Notes 18 $.Width}}
+{{end}}{{end}}
+ARGUMENTS
+{{range .Positionals}}  {{pad .Name 16}}{{.Help}}
+{{end}}
+EXAMPLES
+{{indent 2 .Examples}}
+{{range lines .Footer}}
+# {{.}}{{end}}
+`
+	want := `bang: bang head
+
+USAGE
+  bang --count N [options] NAME
+
+OPTIONS
+  -c, --count N   How many times (required)
+
+Output
+  -v, --verbose   Be verbose (default: false)
+                  (env: BANG_VERBOSE)
+
+ARGUMENTS
+  NAME            The name
+
+EXAMPLES
+  bang sub -c 3
+
+# The footer.
+`
+	var count int
+	var verbose bool
+	var positionals []string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetExamples("bang sub -c 3")
+	cli.SetFooter("The footer.")
+	cli.SetHelpWidth(50)
+	verboseFlag := &clim.Flag{
+		Value: clim.Bool(&verbose, false), Short: "v", Long: "verbose",
+		Help: "Be verbose", Env: "BANG_VERBOSE",
+	}
+	err = cli.AddFlags(
+		&clim.Flag{
+			Value: clim.Int(&count, 3), Short: "c", Long: "count", Label: "N",
+			Help: "How many times", Required: true,
+		},
+		verboseFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlagGroup("Output", verboseFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddPosArgs(&positionals, clim.Pair{"NAME", "The name"})
+	rosina.AssertNoError(t, err)
+	renderer, err := clim.NewTemplateRenderer(tmpl)
+	rosina.AssertNoError(t, err)
+	cli.SetHelpRenderer(renderer)
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
+
+func TestTemplateRendererParseFailure(t *testing.T) {
+	_, err := clim.NewTemplateRenderer("{{.Path")
+
+	rosina.AssertErrorContains(t, err, "clim: help template: template: help:1:")
+}
+
+func TestTemplateRendererExecuteFailure(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	renderer, err := clim.NewTemplateRenderer("{{.Banana}}")
+	rosina.AssertNoError(t, err)
+	cli.SetHelpRenderer(renderer)
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorContains(t, err, "clim: rendering help of bang: template:")
+	rosina.AssertEqual(t, errors.Is(err, clim.ErrHelp), false, "is ErrHelp")
+}
@@ -0,0 +1,157 @@
+// This file contains the expansion of response files (@file arguments).
+
+package clim
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxResponseDepth is the maximum nesting of response files that reference
+// other response files.
+const maxResponseDepth = 10
+
+// SetResponseFiles enables (or disables) the expansion of response files.
+// When enabled, [CLI.Parse] replaces each argument of the form @path with the
+// arguments read from file path, in the same position. Thus flags, values and
+// subcommands in the file behave as if typed on the command-line.
+//
+// The file content is split with shell-like rules:
+//   - arguments are separated by whitespace (including newlines);
+//   - single quotes preserve everything literally;
+//   - double quotes preserve everything, except that a backslash escapes
+//     a double quote or a backslash;
+//   - outside quotes, a backslash escapes the following character;
+//   - a '#' at the beginning of an argument starts a comment, up to the end
+//     of the line.
+//
+// A response file can reference other response files, up to a nesting depth
+// of 10. To pass a literal argument beginning with '@', prefix it with another
+// '@': @@foo becomes @foo.
+func (cli *CLI[T]) SetResponseFiles(enable bool) {
+	cli.rootOnly("SetResponseFiles")
+	cli.responseFiles = enable
+}
+
+// expandResponseFiles returns a copy of 'args', with each @path argument
+// replaced by the arguments read from file path. Parameter 'cmdPath' is the
+// path of the command on which [CLI.Parse] is called. Parameter 'at' is the
+// index, in the args of Parse, of the response file containing 'args', or -1
+// if 'args' are the args of Parse.
+func expandResponseFiles(cmdPath string, args []string, depth int, at int,
+) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+	for i, arg := range args {
+		if len(arg) < 2 || arg[0] != '@' {
+			expanded = append(expanded, arg)
+			continue
+		}
+		if arg[1] == '@' {
+			expanded = append(expanded, arg[1:])
+			continue
+		}
+		path := arg[1:]
+		index := at
+		if index < 0 {
+			index = i
+		}
+		if depth >= maxResponseDepth {
+			return nil, responseError(cmdPath, arg, index,
+				"response file %q: too many nested response files (max %d)",
+				path, maxResponseDepth)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, responseError(cmdPath, arg, index, "response file: %s", err)
+		}
+		tokens, err := splitResponse(string(data))
+		if err != nil {
+			return nil, responseError(cmdPath, arg, index, "response file %q: %s", path, err)
+		}
+		tokens, err = expandResponseFiles(cmdPath, tokens, depth+1, index)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, tokens...)
+	}
+	return expanded, nil
+}
+
+// splitResponse splits 'text' into arguments, following the rules documented
+// in [CLI.SetResponseFiles].
+func splitResponse(text string) ([]string, error) {
+	var tokens []string
+	var tok strings.Builder
+	inToken := false // Needed to keep empty quoted arguments, like ''.
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inToken {
+				tokens = append(tokens, tok.String())
+				tok.Reset()
+				inToken = false
+			}
+		case r == '#' && !inToken:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '\\':
+			i++
+			if i == len(runes) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			tok.WriteRune(runes[i])
+			inToken = true
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			tok.WriteString(string(runes[i+1 : end]))
+			i = end
+			inToken = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) &&
+					(runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				tok.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inToken = true
+		default:
+			tok.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, tok.String())
+	}
+	return tokens, nil
+}
+
+// responseError creates a [ParseError] of kind [KindResponseFile], for the
+// response file argument 'arg' of the command with path 'cmdPath'. Parameter
+// 'index' is the index of 'arg' in the args of [CLI.Parse] or, if 'arg' is in
+// a response file, the index of the top-level response file.
+func responseError(cmdPath string, arg string, index int, format string, a ...any,
+) error {
+	return &ParseError{
+		Kind:  KindResponseFile,
+		Token: arg,
+		Index: index,
+		Path:  cmdPath,
+		msg:   fmt.Sprintf(format, a...),
+	}
+}
@@ -0,0 +1,262 @@
+package clim_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(content), 0o600)
+	rosina.AssertNoError(t, err)
+}
+
+func TestResponseFileSuccess(t *testing.T) {
+	type testCase struct {
+		name    string
+		content string
+		want    []string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		path := filepath.Join(t.TempDir(), "args")
+		writeFile(t, path, tc.content)
+
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetResponseFiles(true)
+		var positionals []string
+		err = cli.AddPosArgs(&positionals, clim.Pair{"ARG...", "Arguments"})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse([]string{"first", "@" + path, "last"})
+
+		rosina.AssertNoError(t, err)
+		want := append(append([]string{"first"}, tc.want...), "last")
+		rosina.AssertDeepEqual(t, positionals, want, "positionals")
+	}
+
+	testCases := []testCase{
+		{
+			name:    "empty file",
+			content: "",
+			want:    []string{},
+		},
+		{
+			name:    "whitespace and newlines",
+			content: " a\tb \n\n c\r\n",
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name:    "comments",
+			content: "# comment\na # another comment\nb#c",
+			want:    []string{"a", "b#c"},
+		},
+		{
+			name:    "single quotes",
+			content: `'a b' 'c"d' '' 'e\f'`,
+			want:    []string{"a b", `c"d`, "", `e\f`},
+		},
+		{
+			name:    "double quotes",
+			content: `"a b" "c'd" "" "e\"f" "g\\h" "i\j"`,
+			want:    []string{"a b", "c'd", "", `e"f`, `g\h`, `i\j`},
+		},
+		{
+			name:    "backslash",
+			content: `a\ b \# \'c`,
+			want:    []string{"a b", "#", "'c"},
+		},
+		{
+			name:    "concatenation",
+			content: `a'b c'"d e"f`,
+			want:    []string{"ab cd ef"},
+		},
+		{
+			name:    "escaped at sign",
+			content: "@@foo",
+			want:    []string{"@foo"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestResponseFileFailure(t *testing.T) {
+	type testCase struct {
+		name    string
+		content string
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		path := filepath.Join(t.TempDir(), "args")
+		writeFile(t, path, tc.content)
+
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetResponseFiles(true)
+
+		_, err = cli.Parse([]string{"@" + path})
+
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name:    "unterminated single quote",
+			content: "'abc",
+			wantErr: "unterminated single quote",
+		},
+		{
+			name:    "unterminated double quote",
+			content: `"abc`,
+			wantErr: "unterminated double quote",
+		},
+		{
+			name:    "trailing backslash",
+			content: `abc\`,
+			wantErr: "trailing backslash",
+		},
+		{
+			name:    "missing nested file",
+			content: "@does-not-exist",
+			wantErr: "response file: open does-not-exist: no such file or directory",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestResponseFileFlagsAndSubcommands(t *testing.T) {
+	dir := t.TempDir()
+	top := filepath.Join(dir, "top")
+	nested := filepath.Join(dir, "nested")
+	writeFile(t, top, "--count 3 sub\n@"+nested+"\n")
+	writeFile(t, nested, "--name='mango juice'")
+
+	var count int
+	var name string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetResponseFiles(true)
+	err = cli.AddFlags(&clim.Flag{Value: clim.Int(&count, 0), Long: "count"})
+	rosina.AssertNoError(t, err)
+	sub, err := clim.NewSub[any](cli, "sub", "I am a subcommand", nil)
+	rosina.AssertNoError(t, err)
+	err = sub.AddFlags(&clim.Flag{Value: clim.String(&name, ""), Long: "name"})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"@" + top})
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, count, 3, "count")
+	rosina.AssertEqual(t, name, "mango juice", "name")
+}
+
+func TestResponseFileTooDeep(t *testing.T) {
+	// A file that references itself.
+	path := filepath.Join(t.TempDir(), "loop")
+	writeFile(t, path, "@"+path)
+
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetResponseFiles(true)
+
+	_, err = cli.Parse([]string{"@" + path})
+
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertErrorContains(t, err, "too many nested response files (max 10)")
+}
+
+func TestResponseFileErrorIndex(t *testing.T) {
+	type testCase struct {
+		name      string
+		args      []string
+		wantToken string
+		wantIndex int
+	}
+
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	top := filepath.Join(dir, "top")
+	writeFile(t, top, "--count 3\n@"+missing+"\n")
+
+	test := func(t *testing.T, tc testCase) {
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetResponseFiles(true)
+		err = cli.AddFlags(&clim.Flag{Value: clim.Int(&count, 0), Long: "count"})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		var perr *clim.ParseError
+		rosina.AssertEqual(t, errors.As(err, &perr), true, "errors.As")
+		rosina.AssertEqual(t, perr.Kind, clim.KindResponseFile, "kind")
+		rosina.AssertEqual(t, perr.Token, tc.wantToken, "token")
+		rosina.AssertEqual(t, perr.Index, tc.wantIndex, "index")
+		rosina.AssertEqual(t, perr.Path, "bang", "path")
+	}
+
+	testCases := []testCase{
+		{
+			name:      "top-level",
+			args:      []string{"--count", "1", "@" + missing},
+			wantToken: "@" + missing,
+			wantIndex: 2,
+		},
+		{
+			name:      "nested, index of the top-level file",
+			args:      []string{"@" + top, "--count", "1"},
+			wantToken: "@" + missing,
+			wantIndex: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestResponseFileUsageHint(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetResponseFiles(true)
+	cli.SetUsageHint(true)
+	_, err = clim.NewSub[any](cli, "sub", "I am sub", nil)
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"@" + missing})
+
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertErrorContains(t, err, `
+Usage: bang [options] <command>
+Run 'bang --help' for usage.`)
+}
+
+func TestResponseFileDisabledByDefault(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	var positionals []string
+	err = cli.AddPosArgs(&positionals, clim.Pair{"ARG...", "Arguments"})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"@does-not-exist"})
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertDeepEqual(t, positionals, []string{"@does-not-exist"},
+		"positionals")
+}
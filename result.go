@@ -0,0 +1,90 @@
+// This file contains the state of a parsing.
+
+package clim
+
+import "slices"
+
+// Result is the state of a successful parsing of a command tree, returned by
+// [CLI.ParseResult]. The methods taking a command 'cmd' accept any command of
+// the tree; a command not traversed by the parsing has no flags set and no
+// positional arguments. A nil Result is valid and empty.
+type Result[T any] struct {
+	action      func(uctx T) error
+	selected    *CLI[T]
+	seen        map[*CLI[T]]map[string]Source // Flags set, and from which source.
+	positionals map[*CLI[T]][]string
+}
+
+func newResult[T any]() *Result[T] {
+	return &Result[T]{
+		seen:        map[*CLI[T]]map[string]Source{},
+		positionals: map[*CLI[T]][]string{},
+	}
+}
+
+// seenOf returns the flags of 'cmd' set so far, creating the map if needed.
+func (res *Result[T]) seenOf(cmd *CLI[T]) map[string]Source {
+	seen := res.seen[cmd]
+	if seen == nil {
+		seen = map[string]Source{}
+		res.seen[cmd] = seen
+	}
+	return seen
+}
+
+// Action returns the action of the selected command, wrapped by the hooks and
+// middlewares (see [CLI.SetBefore] and [CLI.Use]), as returned by [CLI.Parse].
+func (res *Result[T]) Action() func(uctx T) error {
+	if res == nil {
+		return nil
+	}
+	return res.action
+}
+
+// Selected returns the selected command: the top-level CLI itself, or the
+// subcommand of the action. See also [CLI.Selected].
+func (res *Result[T]) Selected() *CLI[T] {
+	if res == nil {
+		return nil
+	}
+	return res.selected
+}
+
+// IsSet reports whether the flag of 'cmd' with long name 'long' has been set,
+// from any source other than the default. See also [CLI.IsSet].
+func (res *Result[T]) IsSet(cmd *CLI[T], long string) bool {
+	return res.FlagSource(cmd, long) != SourceDefault
+}
+
+// FlagSource returns the source of the value of the flag of 'cmd' with long
+// name 'long'. It returns [SourceDefault] also if the flag does not exist.
+// See also [CLI.FlagSource].
+func (res *Result[T]) FlagSource(cmd *CLI[T], long string) Source {
+	if res == nil {
+		return SourceDefault
+	}
+	return res.seen[cmd][long]
+}
+
+// FlagsSet returns the long names of the flags of 'cmd' set from any source
+// other than the default, in the order they were added.
+// See also [CLI.FlagsSet].
+func (res *Result[T]) FlagsSet(cmd *CLI[T]) []string {
+	var longs []string
+	for _, long := range cmd.orderedFlags {
+		if res.IsSet(cmd, long) {
+			longs = append(longs, long)
+		}
+	}
+	return longs
+}
+
+// Positionals returns the positional arguments of 'cmd'. For a command with
+// subcommands, they begin with the name of the subcommand.
+// See also [CLI.Positionals].
+func (res *Result[T]) Positionals(cmd *CLI[T]) []string {
+	if res == nil {
+		return nil
+	}
+	return slices.Clone(res.positionals[cmd])
+}
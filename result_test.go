@@ -0,0 +1,166 @@
+package clim_test
+
+import (
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestParseIsReentrant(t *testing.T) {
+	var count, level int
+	var names []string
+	cli, err := clim.NewTop[any]("bang", "bang head",
+		func(uctx any) error { return nil })
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(
+		&clim.Flag{Value: clim.Int(&count, 0), Long: "count", Required: true},
+		&clim.Flag{Value: clim.Int(&level, 1), Long: "level"},
+		&clim.Flag{Value: clim.StringSlice(&names, nil), Long: "names"})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--count", "3", "--level", "3", "--names=a,b"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, cli.IsSet("count"), true, "first parse: is set")
+	rosina.AssertEqual(t, level, 3, "first parse: level")
+	rosina.AssertDeepEqual(t, names, []string{"a", "b"}, "first parse: names")
+
+	_, err = cli.Parse([]string{"--count", "4"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, cli.IsSet("level"), false, "second parse: is set")
+	rosina.AssertEqual(t, level, 1, "second parse: level")
+	rosina.AssertDeepEqual(t, names, []string(nil), "second parse: names")
+
+	_, err = cli.Parse(nil)
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertEqual(t, err.Error(), "missing required options: count",
+		"third parse: error")
+	rosina.AssertEqual(t, cli.IsSet("count"), false, "third parse: is set")
+	rosina.AssertEqual(t, count, 0, "third parse: count")
+}
+
+// weekday is a user-defined Value, to check that it is restored too.
+type weekday struct{ name string }
+
+func (w *weekday) String() string { return w.name }
+
+func (w *weekday) Set(s string) error {
+	w.name = s
+	return nil
+}
+
+func TestParseRestoresUserDefinedValue(t *testing.T) {
+	day := weekday{name: "monday"}
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{Value: &day, Long: "day"})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--day=friday"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, day.name, "friday", "first parse")
+
+	_, err = cli.Parse(nil)
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, day.name, "monday", "second parse")
+}
+
+func TestParseKeepsVariableSharedByParentAndChild(t *testing.T) {
+	var verbose bool
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{Value: clim.Bool(&verbose, false), Long: "verbose"})
+	rosina.AssertNoError(t, err)
+	sub, err := clim.NewSub[any](cli, "sub", "I am sub",
+		func(uctx any) error { return nil })
+	rosina.AssertNoError(t, err)
+	err = sub.AddFlags(&clim.Flag{Value: clim.Bool(&verbose, false), Long: "verbose"})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--verbose", "sub"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, verbose, true, "set by parent")
+
+	_, err = cli.Parse([]string{"sub"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, verbose, false, "not set")
+
+	_, err = cli.Parse([]string{"sub", "--verbose"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, verbose, true, "set by child")
+}
+
+func TestParseKeepsValueAssignedAfterAddFlags(t *testing.T) {
+	var count int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{Value: clim.Int(&count, 5), Long: "count"})
+	rosina.AssertNoError(t, err)
+	count = 42
+
+	_, err = cli.Parse(nil)
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, count, 42, "first parse")
+
+	_, err = cli.Parse([]string{"--count=1"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, count, 1, "second parse")
+
+	_, err = cli.Parse(nil)
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, count, 42, "third parse")
+}
+
+func TestParseResult(t *testing.T) {
+	var verbose, force bool
+	var level int
+	var names []string
+	action := func(uctx any) error { return nil }
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{Value: clim.Bool(&verbose, false), Long: "verbose"})
+	rosina.AssertNoError(t, err)
+	remote, err := clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+	rosina.AssertNoError(t, err)
+	add, err := clim.NewSub(remote, "add", "Add a remote", action)
+	rosina.AssertNoError(t, err)
+	err = add.AddFlags(
+		&clim.Flag{Value: clim.Bool(&force, false), Long: "force"},
+		&clim.Flag{Value: clim.Int(&level, 0), Long: "level"})
+	rosina.AssertNoError(t, err)
+	err = add.AddPosArgs(&names, clim.Pair{"NAME...", "The names"})
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub(cli, "init", "Create a repo", action)
+	rosina.AssertNoError(t, err)
+
+	res, err := cli.ParseResult(
+		[]string{"--verbose", "remote", "add", "--level=2", "a", "b"})
+	rosina.AssertNoError(t, err)
+
+	rosina.AssertEqual(t, res.Selected() == add, true, "selected")
+	rosina.AssertEqual(t, res.Action() != nil, true, "action")
+	rosina.AssertNoError(t, res.Action()(nil))
+	rosina.AssertEqual(t, res.IsSet(cli, "verbose"), true, "verbose is set")
+	rosina.AssertEqual(t, res.IsSet(add, "force"), false, "force is set")
+	rosina.AssertEqual(t, res.FlagSource(add, "level"), clim.SourceCommandLine,
+		"level source")
+	rosina.AssertDeepEqual(t, res.FlagsSet(add), []string{"level"}, "flags set")
+	rosina.AssertDeepEqual(t, res.Positionals(add), []string{"a", "b"},
+		"positionals")
+	rosina.AssertDeepEqual(t, res.Positionals(cli.Lookup("init")), []string(nil),
+		"positionals of command not traversed")
+
+	// The CLI itself is not modified.
+	rosina.AssertEqual(t, cli.Selected() == nil, true, "cli selected")
+	rosina.AssertEqual(t, cli.IsSet("verbose"), false, "cli verbose is set")
+	rosina.AssertDeepEqual(t, add.Positionals(), []string(nil), "cli positionals")
+
+	// A new result is independent from the previous one.
+	res2, err := cli.ParseResult([]string{"init"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, res2.IsSet(cli, "verbose"), false, "res2 verbose is set")
+	rosina.AssertEqual(t, res.IsSet(cli, "verbose"), true, "res verbose is set")
+
+	_, err = cli.ParseResult([]string{"--banana"})
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+}
@@ -0,0 +1,204 @@
+// This file contains the machine-readable description of the command tree.
+
+package clim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SchemaVersion is the version of the format of [Schema]. It changes only
+// when a change would break a consumer: removing or renaming a field, or
+// changing its meaning. Adding a field does not change the version.
+const SchemaVersion = 1
+
+// Schema is the machine-readable description of a command tree, for external
+// tools such as linters or user interfaces. It marshals to JSON.
+// See [CLI.Schema] and [CLI.SetSchemaFlag].
+type Schema struct {
+	Version int           `json:"version"`
+	Command SchemaCommand `json:"command"`
+}
+
+// SchemaCommand describes a command in [Schema], with all its subcommands.
+// Differently from the help, it contains also the hidden flags and commands.
+type SchemaCommand struct {
+	Name        string             `json:"name"`
+	Path        string             `json:"path"`
+	Oneline     string             `json:"oneline"`
+	Description string             `json:"description,omitempty"`
+	Examples    string             `json:"examples,omitempty"`
+	Footer      string             `json:"footer,omitempty"`
+	Hidden      bool               `json:"hidden,omitempty"`
+	Deprecated  string             `json:"deprecated,omitempty"`
+	ReplacedBy  string             `json:"replaced_by,omitempty"`
+	Flags       []SchemaFlag       `json:"flags"`
+	FlagGroups  []string           `json:"flag_groups,omitempty"`
+	Constraints []SchemaConstraint `json:"constraints,omitempty"`
+	Positionals []SchemaPosArg     `json:"positionals,omitempty"`
+	Groups      []SchemaGroup      `json:"groups,omitempty"`
+	Commands    []SchemaCommand    `json:"commands,omitempty"`
+}
+
+// SchemaFlag describes a flag in [Schema].
+type SchemaFlag struct {
+	Short string `json:"short,omitempty"`
+	Long  string `json:"long"`
+	Label string `json:"label,omitempty"`
+	Help  string `json:"help,omitempty"`
+	// Type is the type of the Value: one of "int", "[]int", "float64",
+	// "string", "[]string", "bool", "duration", "log-level" for the
+	// implementations provided by clim, the Go type otherwise.
+	Type       string `json:"type"`
+	Default    string `json:"default"`
+	Required   bool   `json:"required,omitempty"`
+	Env        string `json:"env,omitempty"`
+	Group      string `json:"group,omitempty"`
+	Hidden     bool   `json:"hidden,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+// SchemaConstraint describes a [Constraint] in [Schema].
+type SchemaConstraint struct {
+	// One of "exactly-one", "at-most-one", "at-least-one", "all-or-none",
+	// "requires". For "requires", the first flag requires the others.
+	Kind  string   `json:"kind"`
+	Flags []string `json:"flags"`
+}
+
+// SchemaPosArg describes a positional argument in [Schema].
+type SchemaPosArg struct {
+	Name string `json:"name"`
+	Help string `json:"help,omitempty"`
+	// Arity is "1" for exactly one argument, "?" for an optional argument
+	// ([NAME]), "1+" for one or more (NAME...), "*" for zero or more
+	// ([NAME...]). See [Pair].
+	Arity string `json:"arity"`
+}
+
+// SchemaGroup describes a group of subcommands in [Schema].
+type SchemaGroup struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+}
+
+// Schema returns the description of cli and of all its subcommands.
+func (cli *CLI[T]) Schema() *Schema {
+	return &Schema{Version: SchemaVersion, Command: cli.schemaCommand()}
+}
+
+// SetSchemaFlag enables a hidden flag with long name 'long' (for example
+// "clim-schema") that makes [CLI.Parse] return the JSON [Schema] of the whole
+// command tree, as an error that unwraps to [ErrHelp] (as the help does).
+// The flag is recognized by all the subcommands.
+func (cli *CLI[T]) SetSchemaFlag(long string) {
+	cli.rootOnly("SetSchemaFlag")
+	cli.schemaFlag = long
+}
+
+// schemaError returns the JSON schema of the whole tree, as an error that
+// unwraps to [ErrHelp].
+func (cli *CLI[T]) schemaError() error {
+	buf, err := json.MarshalIndent(cli.root().Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("clim: marshaling schema: %w", err)
+	}
+	return newHelpError("%s", buf)
+}
+
+func (cli *CLI[T]) schemaCommand() SchemaCommand {
+	sc := SchemaCommand{
+		Name:        cli.name,
+		Path:        cli.rootToHere,
+		Oneline:     cli.oneline,
+		Description: cli.description,
+		Examples:    cli.examples,
+		Footer:      cli.footer,
+		Hidden:      cli.hidden,
+		Deprecated:  cli.deprecated,
+		ReplacedBy:  cli.replacedBy,
+		Flags:       []SchemaFlag{},
+		FlagGroups:  cli.flagGroups,
+	}
+	for _, long := range cli.orderedFlags {
+		flag := cli.long2flag[long]
+		sc.Flags = append(sc.Flags, SchemaFlag{
+			Short:      flag.Short,
+			Long:       flag.Long,
+			Label:      flag.Label,
+			Help:       flag.Help,
+			Type:       valueType(flag.Value),
+			Default:    flag.defValue,
+			Required:   flag.Required,
+			Env:        flag.Env,
+			Group:      flag.group,
+			Hidden:     flag.Hidden,
+			Deprecated: flag.Deprecated,
+			ReplacedBy: flag.ReplacedBy,
+		})
+	}
+	for _, c := range cli.constraints {
+		sc.Constraints = append(sc.Constraints,
+			SchemaConstraint{Kind: c.kind.String(), Flags: c.longs})
+	}
+	for _, pair := range cli.pairs {
+		sc.Positionals = append(sc.Positionals,
+			SchemaPosArg{Name: pair.Name, Help: pair.Help, Arity: arity(pair.Name)})
+	}
+	for _, group := range cli.groups {
+		sg := SchemaGroup{Name: group.name}
+		for _, sub := range group.clis {
+			sg.Commands = append(sg.Commands, sub.name)
+		}
+		sc.Groups = append(sc.Groups, sg)
+	}
+	for _, sub := range cli.subCLIs {
+		sc.Commands = append(sc.Commands, sub.schemaCommand())
+	}
+	return sc
+}
+
+// arity returns the arity of the positional argument with 'name'.
+// See [SchemaPosArg].
+func arity(name string) string {
+	optional := strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]")
+	repeated := strings.HasSuffix(strings.TrimSuffix(name, "]"), "...")
+	switch {
+	case optional && repeated:
+		return "*"
+	case optional:
+		return "?"
+	case repeated:
+		return "1+"
+	default:
+		return "1"
+	}
+}
+
+// valueType returns the name of the type of 'value', for [SchemaFlag].
+func valueType(value Value) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case *intValue:
+		return "int"
+	case *intSliceValue:
+		return "[]int"
+	case *float64Value:
+		return "float64"
+	case *stringValue:
+		return "string"
+	case *stringSliceValue:
+		return "[]string"
+	case *boolValue:
+		return "bool"
+	case *durationValue:
+		return "duration"
+	case *logLevelValue:
+		return "log-level"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
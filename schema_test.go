@@ -0,0 +1,177 @@
+package clim_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestSchema(t *testing.T) {
+	var count int
+	var timeout time.Duration
+	var verbose bool
+	var names []string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetDescription("The description.")
+	verboseFlag := &clim.Flag{
+		Value: clim.Bool(&verbose, false), Short: "v", Long: "verbose",
+		Help: "Be verbose", Env: "BANG_VERBOSE",
+	}
+	err = cli.AddFlags(
+		&clim.Flag{
+			Value: clim.Int(&count, 3), Short: "c", Long: "count", Label: "N",
+			Help: "How many times", Required: true,
+		},
+		&clim.Flag{
+			Value: clim.Duration(&timeout, time.Second), Long: "timeout",
+			Hidden: true,
+		},
+		verboseFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlagGroup("Output", verboseFlag)
+	rosina.AssertNoError(t, err)
+	err = cli.AddConstraints(clim.Requires("verbose", "count"))
+	rosina.AssertNoError(t, err)
+
+	sub, err := clim.NewSub[any](cli, "sub", "I am sub", nil)
+	rosina.AssertNoError(t, err)
+	err = sub.AddPosArgs(&names, clim.Pair{"NAME...", "The names"})
+	rosina.AssertNoError(t, err)
+	old, err := clim.NewSub[any](cli, "old", "I am old", nil)
+	rosina.AssertNoError(t, err)
+	old.SetHidden(true)
+	old.SetDeprecated("renamed", "sub")
+	err = cli.AddGroup("Group", sub)
+	rosina.AssertNoError(t, err)
+
+	have := cli.Schema()
+
+	want := &clim.Schema{
+		Version: clim.SchemaVersion,
+		Command: clim.SchemaCommand{
+			Name:        "bang",
+			Path:        "bang",
+			Oneline:     "bang head",
+			Description: "The description.",
+			Flags: []clim.SchemaFlag{
+				{
+					Short: "c", Long: "count", Label: "N", Help: "How many times",
+					Type: "int", Default: "3", Required: true,
+				},
+				{
+					Long: "timeout", Label: "TIMEOUT", Type: "duration",
+					Default: "1s", Hidden: true,
+				},
+				{
+					Short: "v", Long: "verbose", Help: "Be verbose",
+					Type: "bool", Default: "false", Env: "BANG_VERBOSE",
+					Group: "Output",
+				},
+			},
+			FlagGroups: []string{"Output"},
+			Constraints: []clim.SchemaConstraint{
+				{Kind: "requires", Flags: []string{"verbose", "count"}},
+			},
+			Groups: []clim.SchemaGroup{
+				{Name: "Group", Commands: []string{"sub"}},
+			},
+			Commands: []clim.SchemaCommand{
+				{
+					Name:    "sub",
+					Path:    "bang sub",
+					Oneline: "I am sub",
+					Flags:   []clim.SchemaFlag{},
+					Positionals: []clim.SchemaPosArg{
+						{Name: "NAME...", Help: "The names", Arity: "1+"},
+					},
+				},
+				{
+					Name:       "old",
+					Path:       "bang old",
+					Oneline:    "I am old",
+					Hidden:     true,
+					Deprecated: "renamed",
+					ReplacedBy: "sub",
+					Flags:      []clim.SchemaFlag{},
+				},
+			},
+		},
+	}
+	rosina.AssertDeepEqual(t, have, want, "schema")
+}
+
+func TestSchemaFlag(t *testing.T) {
+	type testCase struct {
+		name string
+		args []string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		cli.SetSchemaFlag("clim-schema")
+		err = cli.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 3), Short: "c", Long: "count", Label: "N",
+			Help: "How many times", Required: true,
+		})
+		rosina.AssertNoError(t, err)
+		_, err = clim.NewSub[any](cli, "sub", "I am sub", nil)
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertErrorIs(t, err, clim.ErrHelp)
+		var have clim.Schema
+		err = json.Unmarshal([]byte(err.Error()), &have)
+		rosina.AssertNoError(t, err)
+		rosina.AssertDeepEqual(t, &have, cli.Schema(), "schema")
+	}
+
+	testCases := []testCase{
+		{
+			name: "top-level",
+			args: []string{"--clim-schema"},
+		},
+		{
+			name: "subcommand",
+			args: []string{"-c", "1", "sub", "--clim-schema"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestSchemaFlagDisabled(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--clim-schema"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertEqual(t, errors.Is(err, clim.ErrHelp), false, "is ErrHelp")
+}
+
+func TestSchemaArity(t *testing.T) {
+	var names []string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddPosArgs(&names,
+		clim.Pair{"A", ""}, clim.Pair{"[B]", ""},
+		clim.Pair{"C...", ""}, clim.Pair{"[D...]", ""})
+	rosina.AssertNoError(t, err)
+
+	var have []string
+	for _, pa := range cli.Schema().Command.Positionals {
+		have = append(have, pa.Arity)
+	}
+
+	rosina.AssertDeepEqual(t, have, []string{"1", "?", "1+", "*"}, "arity")
+}
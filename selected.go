@@ -0,0 +1,47 @@
+// This file contains the introspection of the command tree.
+
+package clim
+
+// Selected returns the command selected by the last successful [CLI.Parse]
+// of the tree of cli: the top-level CLI itself, or the subcommand whose action
+// Parse returned. It returns nil if Parse has not been called or failed
+// (including when the help was requested).
+// Use it, for example, for metrics, logging or per-command setup:
+//
+//	action, err := cli.Parse(args)
+//	...
+//	log.Println("running", cli.Selected().Path(), cli.Selected().FlagsSet())
+func (cli *CLI[T]) Selected() *CLI[T] {
+	return cli.root().last.Selected()
+}
+
+// Name returns the name of cli, for example "clone".
+func (cli *CLI[T]) Name() string {
+	return cli.name
+}
+
+// Path returns the names of the commands from the top-level CLI to cli,
+// separated by a space, for example "hg clone".
+func (cli *CLI[T]) Path() string {
+	return cli.rootToHere
+}
+
+// Positionals returns the positional arguments of cli set by the last
+// [CLI.Parse]. For a command with subcommands, they begin with the name of the
+// subcommand.
+func (cli *CLI[T]) Positionals() []string {
+	return cli.root().last.Positionals(cli)
+}
+
+// Lookup returns the descendant of cli with the given 'names', for example
+// cli.Lookup("remote", "add"), or cli itself if no names. It returns nil if
+// not found.
+func (cli *CLI[T]) Lookup(names ...string) *CLI[T] {
+	node := cli
+	for _, name := range names {
+		if node = node.findSub(name); node == nil {
+			return nil
+		}
+	}
+	return node
+}
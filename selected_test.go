@@ -0,0 +1,73 @@
+package clim_test
+
+import (
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestSelected(t *testing.T) {
+	var verbose, force bool
+	var level int
+	var names []string
+	action := func(uctx any) error { return nil }
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{Value: clim.Bool(&verbose, false), Long: "verbose"})
+	rosina.AssertNoError(t, err)
+	remote, err := clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+	rosina.AssertNoError(t, err)
+	add, err := clim.NewSub(remote, "add", "Add a remote", action)
+	rosina.AssertNoError(t, err)
+	err = add.AddFlags(
+		&clim.Flag{Value: clim.Bool(&force, false), Long: "force"},
+		&clim.Flag{Value: clim.Int(&level, 0), Long: "level"})
+	rosina.AssertNoError(t, err)
+	err = add.AddPosArgs(&names, clim.Pair{"NAME...", "The names"})
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub(cli, "init", "Create a repo", action)
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, cli.Selected() == nil, true, "before Parse")
+
+	_, err = cli.Parse([]string{"--verbose", "remote", "add", "--level=2", "a", "b"})
+	rosina.AssertNoError(t, err)
+
+	sel := cli.Selected()
+	rosina.AssertEqual(t, sel == cli.Lookup("remote", "add"), true, "selected")
+	rosina.AssertEqual(t, sel.Name(), "add", "name")
+	rosina.AssertEqual(t, sel.Path(), "bang remote add", "path")
+	rosina.AssertDeepEqual(t, sel.Positionals(), []string{"a", "b"}, "positionals")
+	rosina.AssertDeepEqual(t, sel.FlagsSet(), []string{"level"}, "flags set")
+	rosina.AssertDeepEqual(t, cli.FlagsSet(), []string{"verbose"}, "top flags set")
+	rosina.AssertEqual(t, sel.Selected() == sel, true, "from any node")
+	rosina.AssertDeepEqual(t, cli.Lookup("remote").Positionals(),
+		[]string{"add", "--level=2", "a", "b"}, "positionals of remote")
+
+	_, err = cli.Parse([]string{"init", "--banana"})
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertEqual(t, cli.Selected() == nil, true, "after failed Parse")
+
+	_, err = cli.Parse([]string{"remote", "--help"})
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertEqual(t, cli.Selected() == nil, true, "after help")
+}
+
+func TestLookup(t *testing.T) {
+	action := func(uctx any) error { return nil }
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	remote, err := clim.NewSub[any](cli, "remote", "Manage remotes", nil)
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub(remote, "add", "Add a remote", action)
+	rosina.AssertNoError(t, err)
+	_, err = clim.NewSub(cli, "init", "Create a repo", action)
+	rosina.AssertNoError(t, err)
+
+	rosina.AssertEqual(t, cli.Lookup() == cli, true, "no names")
+	rosina.AssertEqual(t, cli.Lookup("init").Path(), "bang init", "init")
+	rosina.AssertEqual(t, cli.Lookup("remote").Lookup("add").Path(),
+		"bang remote add", "relative")
+	rosina.AssertEqual(t, cli.Lookup("remote", "banana") == nil, true, "not found")
+	rosina.AssertEqual(t, cli.Lookup("banana", "add") == nil, true, "not found")
+}
@@ -0,0 +1,79 @@
+// This file contains the runner that cancels a context on SIGINT and SIGTERM.
+
+package clim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ExitCodeContext is like [ExitCode], but it also handles the termination
+// signals. It calls mainErr(ctx, args), where 'ctx' is cancelled when the
+// process receives SIGINT (Ctrl-C) or SIGTERM. Pass 'ctx' to the actions via
+// the type parameter T of [CLI], so that they can stop their work and return.
+//
+// After the signal, ExitCodeContext waits at most 'grace' for mainErr to
+// return; if mainErr does not return in time, ExitCodeContext prints a
+// message and returns [ExitInterrupted] without waiting further (the
+// goroutine running mainErr is abandoned, the caller is expected to exit).
+// During the grace period the signals have their default behavior, thus a
+// second Ctrl-C terminates the process immediately.
+//
+// After the signal, if mainErr returns nil or an error matching
+// context.Canceled, the exit code is [ExitInterrupted] (130); any other error
+// is mapped as in [ExitCode].
+//
+// Usage:
+//
+//	func main() {
+//	    os.Exit(clim.ExitCodeContext(mainErr, os.Args[1:], os.Stderr,
+//	        5*time.Second))
+//	}
+//
+//	func mainErr(ctx context.Context, args []string) error {
+//	    cli, err := clim.NewTop[context.Context]("bang", "bang head", run)
+//	    ...
+//	    action, err := cli.Parse(args)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return action(ctx)
+//	}
+func ExitCodeContext(
+	mainErr func(ctx context.Context, args []string) error,
+	args []string, out io.Writer, grace time.Duration, mappings ...ExitMapping,
+) int {
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() { done <- mainErr(ctx, args) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Restore the default behavior: a second signal terminates the process.
+		stop()
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case err = <-done:
+		case <-timer.C:
+			fmt.Fprintf(out, "interrupted: not terminated within %v\n", grace)
+			return ExitInterrupted
+		}
+	}
+
+	if ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)) {
+		err = Exit(ExitInterrupted, err)
+	}
+	return exitWith(err, out, mappings)
+}
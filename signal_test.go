@@ -0,0 +1,125 @@
+package clim_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+// interrupt sends SIGINT to the test process, which is safe only while
+// clim.ExitCodeContext is running. It can be called from any goroutine.
+func interrupt(t *testing.T) {
+	proc, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = proc.Signal(os.Interrupt)
+	}
+	if err != nil {
+		t.Errorf("sending SIGINT: %v", err)
+	}
+}
+
+func skipIfNoSignals(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("cannot send SIGINT on windows")
+	}
+}
+
+func TestExitCodeContext(t *testing.T) {
+	skipIfNoSignals(t)
+	type testCase struct {
+		name     string
+		mainErr  func(t *testing.T, ctx context.Context) error
+		wantCode int
+		wantOut  string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		out := &strings.Builder{}
+		mainErr := func(ctx context.Context, args []string) error {
+			return tc.mainErr(t, ctx)
+		}
+
+		code := clim.ExitCodeContext(mainErr, nil, out, time.Second)
+
+		rosina.AssertEqual(t, code, tc.wantCode, "exit code")
+		rosina.AssertTextEqual(t, out.String(), tc.wantOut, "output")
+	}
+
+	testCases := []testCase{
+		{
+			name: "success",
+			mainErr: func(t *testing.T, ctx context.Context) error {
+				return nil
+			},
+			wantCode: 0,
+		},
+		{
+			name: "parse error",
+			mainErr: func(t *testing.T, ctx context.Context) error {
+				return clim.NewParseError("bad")
+			},
+			wantCode: 2,
+			wantOut:  "bad\n",
+		},
+		{
+			name: "interrupted, returns the context error",
+			mainErr: func(t *testing.T, ctx context.Context) error {
+				interrupt(t)
+				<-ctx.Done()
+				return fmt.Errorf("fetching: %w", ctx.Err())
+			},
+			wantCode: 130,
+			wantOut:  "fetching: context canceled\n",
+		},
+		{
+			name: "interrupted, returns nil",
+			mainErr: func(t *testing.T, ctx context.Context) error {
+				interrupt(t)
+				<-ctx.Done()
+				return nil
+			},
+			wantCode: 130,
+		},
+		{
+			name: "interrupted, returns another error",
+			mainErr: func(t *testing.T, ctx context.Context) error {
+				interrupt(t)
+				<-ctx.Done()
+				return errors.New("cleanup failed")
+			},
+			wantCode: 1,
+			wantOut:  "cleanup failed\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestExitCodeContextGracePeriod(t *testing.T) {
+	skipIfNoSignals(t)
+	release := make(chan struct{})
+	defer close(release)
+	mainErr := func(ctx context.Context, args []string) error {
+		interrupt(t)
+		<-release // Ignore the cancellation.
+		return nil
+	}
+	out := &strings.Builder{}
+
+	code := clim.ExitCodeContext(mainErr, nil, out, 10*time.Millisecond)
+
+	rosina.AssertEqual(t, code, 130, "exit code")
+	rosina.AssertTextEqual(t, out.String(),
+		"interrupted: not terminated within 10ms\n", "output")
+}
@@ -0,0 +1,131 @@
+// This file contains the sources of the flag values, other than the
+// command-line.
+
+package clim
+
+import (
+	"fmt"
+	"os"
+)
+
+// Source tells where the value of a flag comes from.
+// In order of increasing precedence: [SourceDefault], [SourceConfig],
+// [SourceEnv], [SourceCommandLine].
+type Source int
+
+const (
+	SourceDefault     Source = iota // Not set: the value is the default.
+	SourceConfig                    // Set by the lookup of CLI.SetConfigLookup.
+	SourceEnv                       // Set by the environment variable Flag.Env.
+	SourceCommandLine               // Set on the command-line.
+)
+
+func (src Source) String() string {
+	switch src {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCommandLine:
+		return "command-line"
+	default:
+		return fmt.Sprintf("Source(%d)", int(src))
+	}
+}
+
+// ConfigLookup is the signature of a function that returns the value of the
+// flag with long name 'long', belonging to the command with path 'path' (for
+// example "hg clone"), and true if found. See [CLI.SetConfigLookup].
+type ConfigLookup func(path string, long string) (value string, found bool)
+
+// SetConfigLookup sets the function that [CLI.Parse] calls for each flag of cli
+// and of its subcommands that has not been set on the command-line nor by its
+// environment variable. A subcommand can override it with its own lookup.
+//
+// This allows to take flag values from a configuration file, without clim
+// knowing anything about the configuration format.
+func (cli *CLI[T]) SetConfigLookup(lookup ConfigLookup) {
+	cli.configLookup = lookup
+}
+
+// IsSet reports whether the flag with long name 'long' has been set by the
+// last [CLI.Parse], from any source other than the default.
+func (cli *CLI[T]) IsSet(long string) bool {
+	return cli.root().last.IsSet(cli, long)
+}
+
+// FlagSource returns the source of the value of the flag with long name 'long',
+// as determined by the last [CLI.Parse]. It returns [SourceDefault] also if
+// the flag does not exist.
+func (cli *CLI[T]) FlagSource(long string) Source {
+	return cli.root().last.FlagSource(cli, long)
+}
+
+// FlagsSet returns the long names of the flags of cli set by the last
+// [CLI.Parse], from any source other than the default, in the order they were
+// added. See also [CLI.FlagSource].
+func (cli *CLI[T]) FlagsSet() []string {
+	return cli.root().last.FlagsSet(cli)
+}
+
+// findConfigLookup returns the config lookup of the nearest node, from cli up
+// to the root, that has one. It returns nil if none.
+func (cli *CLI[T]) findConfigLookup() ConfigLookup {
+	for node := cli; node != nil; node = node.parent {
+		if node.configLookup != nil {
+			return node.configLookup
+		}
+	}
+	return nil
+}
+
+// setFromOtherSources sets the flags not set on the command-line (not in
+// 'seen'), taking the value from the environment or from the configuration,
+// and records their source in 'seen'. It skips the flags excluded by a flag
+// given on the command-line (see [Constraint]).
+func (cli *CLI[T]) setFromOtherSources(seen map[string]Source) error {
+	lookup := cli.findConfigLookup()
+	for _, long := range cli.orderedFlags {
+		if _, found := seen[long]; found {
+			continue
+		}
+		if cli.excludedByCommandLine(long, seen) {
+			continue
+		}
+		flag := cli.long2flag[long]
+		if flag.Value == nil {
+			// Forwarding deprecated flag.
+			continue
+		}
+		if flag.Env != "" {
+			if value, found := os.LookupEnv(flag.Env); found {
+				if err := flag.Value.Set(value); err != nil {
+					return cli.newParseError(KindBadValue,
+						"setting --%s from env %s=%q: %s",
+						long, flag.Env, value, err).with(long, err)
+				}
+				if err := cli.validateFlag(flag, "", -1); err != nil {
+					return err
+				}
+				seen[long] = SourceEnv
+				continue
+			}
+		}
+		if lookup != nil {
+			if value, found := lookup(cli.rootToHere, long); found {
+				if err := flag.Value.Set(value); err != nil {
+					return cli.newParseError(KindBadValue,
+						"setting --%s from config %q: %s",
+						long, value, err).with(long, err)
+				}
+				if err := cli.validateFlag(flag, "", -1); err != nil {
+					return err
+				}
+				seen[long] = SourceConfig
+			}
+		}
+	}
+	return nil
+}
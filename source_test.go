@@ -0,0 +1,212 @@
+package clim_test
+
+import (
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+func TestFlagSource(t *testing.T) {
+	type testCase struct {
+		name       string
+		args       []string
+		env        string // value of BANG_COUNT; empty means unset
+		config     map[string]string
+		wantCount  int
+		wantSource clim.Source
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		if tc.env != "" {
+			t.Setenv("BANG_COUNT", tc.env)
+		}
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 3), Long: "count", Env: "BANG_COUNT",
+		})
+		rosina.AssertNoError(t, err)
+		cli.SetConfigLookup(func(path, long string) (string, bool) {
+			value, found := tc.config[path+" "+long]
+			return value, found
+		})
+
+		_, err = cli.Parse(tc.args)
+
+		rosina.AssertNoError(t, err)
+		rosina.AssertEqual(t, count, tc.wantCount, "count")
+		rosina.AssertEqual(t, cli.FlagSource("count"), tc.wantSource, "source")
+		rosina.AssertEqual(t, cli.IsSet("count"),
+			tc.wantSource != clim.SourceDefault, "IsSet")
+	}
+
+	testCases := []testCase{
+		{
+			name:       "default",
+			wantCount:  3,
+			wantSource: clim.SourceDefault,
+		},
+		{
+			name:       "command-line, same value as default",
+			args:       []string{"--count", "3"},
+			wantCount:  3,
+			wantSource: clim.SourceCommandLine,
+		},
+		{
+			name:       "config",
+			config:     map[string]string{"bang count": "5"},
+			wantCount:  5,
+			wantSource: clim.SourceConfig,
+		},
+		{
+			name:       "env wins over config",
+			env:        "7",
+			config:     map[string]string{"bang count": "5"},
+			wantCount:  7,
+			wantSource: clim.SourceEnv,
+		},
+		{
+			name:       "command-line wins over env and config",
+			args:       []string{"--count=9"},
+			env:        "7",
+			config:     map[string]string{"bang count": "5"},
+			wantCount:  9,
+			wantSource: clim.SourceCommandLine,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestFlagSourceUnknownFlag(t *testing.T) {
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+
+	rosina.AssertEqual(t, cli.FlagSource("banana"), clim.SourceDefault, "source")
+	rosina.AssertEqual(t, cli.IsSet("banana"), false, "IsSet")
+}
+
+func TestSourceString(t *testing.T) {
+	rosina.AssertEqual(t, clim.SourceDefault.String(), "default", "default")
+	rosina.AssertEqual(t, clim.SourceConfig.String(), "config", "config")
+	rosina.AssertEqual(t, clim.SourceEnv.String(), "env", "env")
+	rosina.AssertEqual(t, clim.SourceCommandLine.String(), "command-line",
+		"command-line")
+	rosina.AssertEqual(t, clim.Source(42).String(), "Source(42)", "unknown")
+}
+
+func TestConfigLookupInheritedBySubcommand(t *testing.T) {
+	var name string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	cli.SetConfigLookup(func(path, long string) (string, bool) {
+		return path + "." + long, true
+	})
+	sub, err := clim.NewSub[any](cli, "sub", "I am a subcommand", nil)
+	rosina.AssertNoError(t, err)
+	err = sub.AddFlags(&clim.Flag{
+		Value: clim.String(&name, ""), Long: "name", Required: true,
+	})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"sub"})
+
+	rosina.AssertNoError(t, err)
+	rosina.AssertEqual(t, name, "bang sub.name", "name")
+	rosina.AssertEqual(t, sub.FlagSource("name"), clim.SourceConfig, "source")
+}
+
+func TestOtherSourcesFailure(t *testing.T) {
+	type testCase struct {
+		name    string
+		env     string // value of BANG_COUNT; empty means unset
+		config  string // empty means not found
+		wantErr string
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		if tc.env != "" {
+			t.Setenv("BANG_COUNT", tc.env)
+		}
+		var count int
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		err = cli.AddFlags(&clim.Flag{
+			Value: clim.Int(&count, 3), Long: "count", Env: "BANG_COUNT",
+			Validate: func() error {
+				if count < 0 {
+					return errTooBig
+				}
+				return nil
+			},
+		})
+		rosina.AssertNoError(t, err)
+		cli.SetConfigLookup(func(path, long string) (string, bool) {
+			return tc.config, tc.config != ""
+		})
+
+		_, err = cli.Parse(nil)
+
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name: "env, bad value",
+			env:  "banana",
+			wantErr: `setting --count from env BANG_COUNT="banana": ` +
+				`could not parse "banana" as int`,
+		},
+		{
+			name:    "env, validation",
+			env:     "-1",
+			wantErr: "bang: --count: too big",
+		},
+		{
+			name:    "config, bad value",
+			config:  "banana",
+			wantErr: `setting --count from config "banana": could not parse`,
+		},
+		{
+			name:    "config, validation",
+			config:  "-1",
+			wantErr: "bang: --count: too big",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestHelpOfEnv(t *testing.T) {
+	var count int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{
+		Value: clim.Int(&count, 3), Long: "count", Env: "BANG_COUNT",
+		Help: "How many times",
+	})
+	rosina.AssertNoError(t, err)
+
+	want := `bang -- bang head
+
+Usage: bang [options]
+
+Options:
+
+ --count COUNT    How many times (default: 3) (env: BANG_COUNT)
+
+ -h, --help       Print this help and exit
+`
+
+	_, err = cli.Parse([]string{"-h"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrHelp)
+	rosina.AssertTextEqual(t, err.Error(), want, "help message")
+}
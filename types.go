@@ -6,6 +6,7 @@ package clim
 import (
 	"fmt"
 	"log/slog"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +21,21 @@ type Value interface {
 	Set(string) error
 }
 
+// resetter returns a function that restores the current value of 'value', so
+// that each [CLI.Parse] starts from the default values. It returns nil if
+// 'value' is not a pointer (all the types provided by clim are).
+// The copy is shallow: a Value that modifies in place the elements of a slice
+// or of a map (instead of replacing them) is not completely restored.
+func resetter(value Value) func() {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil
+	}
+	saved := reflect.New(rv.Elem().Type()).Elem()
+	saved.Set(rv.Elem())
+	return func() { rv.Elem().Set(saved) }
+}
+
 // boolFlag is an interface to be implemented by boolean types (in addition to
 // the [Value] interface), to indicate that the flag can be supplied without
 // "=value" text.
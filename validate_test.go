@@ -0,0 +1,140 @@
+package clim_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marco-m/clim"
+	"github.com/marco-m/rosina"
+)
+
+var errTooBig = errors.New("too big")
+
+func TestFlagValidate(t *testing.T) {
+	type testCase struct {
+		name    string
+		args    []string
+		wantErr string // empty means no error
+	}
+
+	test := func(t *testing.T, tc testCase) {
+		var count int
+		var verbose bool
+		cli, err := clim.NewTop[any]("bang", "bang head", nil)
+		rosina.AssertNoError(t, err)
+		sub, err := clim.NewSub[any](cli, "sub", "I am a subcommand", nil)
+		rosina.AssertNoError(t, err)
+		err = sub.AddFlags(
+			&clim.Flag{
+				Value: clim.Int(&count, 0), Short: "c", Long: "count",
+				Validate: func() error {
+					if count > 10 {
+						return errTooBig
+					}
+					return nil
+				},
+			},
+			&clim.Flag{
+				Value: clim.Bool(&verbose, false), Long: "verbose",
+				Validate: func() error {
+					return errors.New("not today")
+				},
+			})
+		rosina.AssertNoError(t, err)
+
+		_, err = cli.Parse(tc.args)
+
+		if tc.wantErr == "" {
+			rosina.AssertNoError(t, err)
+			return
+		}
+		rosina.AssertErrorIs(t, err, clim.ErrParse)
+		rosina.AssertErrorContains(t, err, tc.wantErr)
+	}
+
+	testCases := []testCase{
+		{
+			name: "valid",
+			args: []string{"sub", "--count", "10"},
+		},
+		{
+			name:    "invalid, separate value",
+			args:    []string{"sub", "-c", "11"},
+			wantErr: "bang sub: --count: too big",
+		},
+		{
+			name:    "invalid, value with =",
+			args:    []string{"sub", "--count=11"},
+			wantErr: "bang sub: --count: too big",
+		},
+		{
+			name:    "invalid, bool",
+			args:    []string{"sub", "--verbose"},
+			wantErr: "bang sub: --verbose: not today",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) { test(t, tc) })
+	}
+}
+
+func TestFlagValidateUnwrapsToUserError(t *testing.T) {
+	var count int
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{
+		Value: clim.Int(&count, 0), Long: "count",
+		Validate: func() error { return errTooBig },
+	})
+	rosina.AssertNoError(t, err)
+
+	_, err = cli.Parse([]string{"--count=1"})
+
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertErrorIs(t, err, errTooBig)
+}
+
+func TestCLIValidate(t *testing.T) {
+	var count int
+	var positionals []string
+	var calls []string
+	cli, err := clim.NewTop[any]("bang", "bang head", nil)
+	rosina.AssertNoError(t, err)
+	err = cli.AddFlags(&clim.Flag{Value: clim.Int(&count, 0), Long: "count"})
+	rosina.AssertNoError(t, err)
+	cli.SetValidate(func() error {
+		calls = append(calls, "bang")
+		if count == 0 {
+			return errors.New("count cannot be 0")
+		}
+		return nil
+	})
+	sub, err := clim.NewSub[any](cli, "sub", "I am a subcommand", nil)
+	rosina.AssertNoError(t, err)
+	err = sub.AddPosArgs(&positionals, clim.Pair{"NAME...", "Names"})
+	rosina.AssertNoError(t, err)
+	sub.SetValidate(func() error {
+		calls = append(calls, "sub")
+		if len(positionals) > count {
+			return errors.New("too many names")
+		}
+		return nil
+	})
+
+	_, err = cli.Parse([]string{"--count=2", "sub", "a", "b"})
+	rosina.AssertNoError(t, err)
+	rosina.AssertDeepEqual(t, calls, []string{"bang", "sub"}, "calls")
+
+	calls = nil
+	_, err = cli.Parse([]string{"--count=1", "sub", "a", "b"})
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertErrorContains(t, err, "bang sub: too many names")
+	rosina.AssertDeepEqual(t, calls, []string{"bang", "sub"}, "calls")
+
+	calls = nil
+	_, err = cli.Parse([]string{"--count=0", "sub", "a"})
+	rosina.AssertErrorIs(t, err, clim.ErrParse)
+	rosina.AssertErrorContains(t, err, "bang: count cannot be 0")
+	rosina.AssertDeepEqual(t, calls, []string{"bang"}, "calls")
+}